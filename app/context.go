@@ -1,28 +1,19 @@
 package app
 
-
-import (
-	"github.com/danakum/go-util/log"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
-)
-
-type config struct {
+type surgeConfig struct {
 	Enabled bool   `yaml:"enabled" json:"enabled"`
 	BaseUrl string `yaml:"base_url" json:"base_url"`
 }
 
-var Config config
-
-func Init() {
-	file, err := ioutil.ReadFile(`config/surge.yaml`)
-	if err != nil {
-		log.Fatal(`Cannot open config file`, `, config/mqtt.yaml, `, err)
-	}
+//Config Surge settings, loaded from config/surge.yaml by Init. Kept for services which
+//read it directly, new settings should register their own Section.
+//Unknown keys of surge.yaml are ignored as they always were
+var Config surgeConfig
 
-	err = yaml.Unmarshal(file, &Config)
-	if err != nil {
-		log.Fatal(`Cannot parse config file`, `config/mqtt.yaml`, err)
-	}
-	log.Info(`Surge config established:`, Config)
+func init() {
+	Register(Section{
+		Name:               `surge`,
+		Config:             &Config,
+		AllowUnknownFields: true,
+	})
 }
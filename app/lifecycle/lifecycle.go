@@ -0,0 +1,328 @@
+//Package lifecycle Start and stop the components of an application in dependency order.
+//
+//Components start after the components they depend on and stop before them, so a consumer
+//depending on a database stops consuming and drains its in-flight work before the database
+//is closed. Once Run (or HandleSignals) is called, SIGINT and SIGTERM stop every component
+//within the shutdown timeout of config/app
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/log"
+)
+
+//DefaultShutdownTimeout Used when config/app does not set shutdown_timeout
+const DefaultShutdownTimeout = 30 * time.Second
+
+//Component A part of the application with start and stop hooks, both optional. Components
+//registered without a start hook are considered running (ex: connections opened by an Init)
+type Component struct {
+	Name string
+	//DependsOn Names of the components to start before and stop after this one. A name also
+	//covers its instances (mysql covers mysql:reports) and names never registered are ignored
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+}
+
+type component struct {
+	Component
+	running bool
+}
+
+type Lifecycle struct {
+	mu         *sync.Mutex
+	components []*component
+	shutdown   *sync.Once
+	signals    *sync.Once
+	done       chan struct{}
+	err        error
+	waiters    int32
+}
+
+//Default Lifecycle the connection packages (mysql, postgre, redis, mqtt) register with
+var Default = New()
+
+func New() *Lifecycle {
+	return &Lifecycle{
+		mu:       &sync.Mutex{},
+		shutdown: &sync.Once{},
+		signals:  &sync.Once{},
+		done:     make(chan struct{}),
+	}
+}
+
+//Register Add a component. Registering a name twice replaces the previous component
+func (l *Lifecycle) Register(c Component) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	registered := &component{Component: c, running: c.Start == nil}
+	for i, existing := range l.components {
+		if existing.Name == c.Name {
+			l.components[i] = registered
+			return
+		}
+	}
+
+	l.components = append(l.components, registered)
+}
+
+//Start Start the components not running yet, dependencies first. When a component fails the
+//ones already running are stopped and the error is returned
+func (l *Lifecycle) Start(ctx context.Context) error {
+	ordered, err := l.ordered()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range ordered {
+		if l.isRunning(c) {
+			continue
+		}
+
+		if err := c.Start(ctx); err != nil {
+			err = fmt.Errorf(`lifecycle: cannot start %s : %w`, c.Name, err)
+			log.Error(err)
+
+			stopCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout())
+			defer cancel()
+			return config.Errors{err}.Append(l.Stop(stopCtx)).Err()
+		}
+
+		l.setRunning(c, true)
+		log.Info(`lifecycle: ` + c.Name + ` started`)
+	}
+
+	return nil
+}
+
+//Stop Stop the running components, dependents first. Each stop hook gets ctx, a hook still
+//running when ctx is done is reported and left behind so the others can still be stopped
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	ordered, err := l.ordered()
+	if err != nil {
+		//stop in reverse registration order rather than not at all
+		log.Error(err)
+		ordered = l.registered()
+	}
+
+	errs := make(config.Errors, 0)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		c := ordered[i]
+		if !l.setRunning(c, false) {
+			continue
+		}
+
+		if c.Stop == nil {
+			continue
+		}
+
+		if err := stop(ctx, c); err != nil {
+			log.Error(err)
+			errs = errs.Append(err)
+			continue
+		}
+
+		log.Info(`lifecycle: ` + c.Name + ` stopped`)
+	}
+
+	return errs.Err()
+}
+
+func (l *Lifecycle) isRunning(c *component) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return c.running
+}
+
+//setRunning Change the state of a component, false when it already was in that state
+func (l *Lifecycle) setRunning(c *component, running bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c.running == running {
+		return false
+	}
+	c.running = running
+
+	return true
+}
+
+func stop(ctx context.Context, c *component) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Stop(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return fmt.Errorf(`lifecycle: cannot stop %s : %w`, c.Name, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf(`lifecycle: %s did not stop before the deadline : %w`, c.Name, ctx.Err())
+	}
+}
+
+//Shutdown Stop every component within ShutdownTimeout, once. Wait returns afterwards
+func (l *Lifecycle) Shutdown() error {
+	l.shutdown.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout())
+		defer cancel()
+
+		l.err = l.Stop(ctx)
+		close(l.done)
+	})
+
+	<-l.done
+	return l.err
+}
+
+//HandleSignals Shutdown on SIGINT or SIGTERM, a second signal exits at once. When nothing
+//waits for the lifecycle (see Wait) the process exits once the components are stopped.
+//Called by Run, applications handling signals themselves call Shutdown instead
+func (l *Lifecycle) HandleSignals() {
+	l.signals.Do(func() {
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			sig := <-signals
+			log.Info(`lifecycle: shutting down on signal `, sig)
+
+			go func() {
+				sig := <-signals
+				log.Error(`lifecycle: exiting on second signal `, sig)
+				os.Exit(1)
+			}()
+
+			err := l.Shutdown()
+			if atomic.LoadInt32(&l.waiters) > 0 {
+				return
+			}
+
+			if err != nil {
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}()
+	})
+}
+
+//Wait Block until the lifecycle is shut down and return the errors of the stop hooks
+func (l *Lifecycle) Wait() error {
+	atomic.AddInt32(&l.waiters, 1)
+	defer atomic.AddInt32(&l.waiters, -1)
+
+	<-l.done
+	return l.err
+}
+
+//Run Start every component, handle signals and block until shutdown, which a cancelled ctx also triggers
+func (l *Lifecycle) Run(ctx context.Context) error {
+	atomic.AddInt32(&l.waiters, 1)
+	defer atomic.AddInt32(&l.waiters, -1)
+
+	l.HandleSignals()
+
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return l.Shutdown()
+	case <-l.done:
+		return l.err
+	}
+}
+
+//ShutdownTimeout Deadline of a shutdown, shutdown_timeout of config/app
+func ShutdownTimeout() time.Duration {
+	if config.AppConf.ShutdownTimeout > 0 {
+		return config.AppConf.ShutdownTimeout
+	}
+
+	return DefaultShutdownTimeout
+}
+
+func (l *Lifecycle) registered() []*component {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]*component{}, l.components...)
+}
+
+//ordered Components sorted so each one comes after its dependencies, otherwise in registration order
+func (l *Lifecycle) ordered() ([]*component, error) {
+	components := l.registered()
+
+	ordered := make([]*component, 0, len(components))
+	state := make(map[*component]int) //1 visiting, 2 done
+
+	var visit func(c *component, path []string) error
+	visit = func(c *component, path []string) error {
+		switch state[c] {
+		case 1:
+			return fmt.Errorf(`lifecycle: dependency cycle %s`, strings.Join(append(path, c.Name), ` -> `))
+		case 2:
+			return nil
+		}
+
+		state[c] = 1
+		for _, dependency := range c.DependsOn {
+			for _, d := range components {
+				if d != c && (d.Name == dependency || strings.HasPrefix(d.Name, dependency+`:`)) {
+					if err := visit(d, append(path, c.Name)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		state[c] = 2
+
+		ordered = append(ordered, c)
+		return nil
+	}
+
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+//Register Add a component to the Default lifecycle
+func Register(c Component) {
+	Default.Register(c)
+}
+
+//Run Run the Default lifecycle
+func Run(ctx context.Context) error {
+	return Default.Run(ctx)
+}
+
+//Shutdown Shutdown the Default lifecycle
+func Shutdown() error {
+	return Default.Shutdown()
+}
+
+//Wait Wait for the shutdown of the Default lifecycle
+func Wait() error {
+	return Default.Wait()
+}
@@ -0,0 +1,213 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//recorder Records the start and stop hooks called, in order
+type recorder struct {
+	mu    *sync.Mutex
+	calls []string
+}
+
+func newRecorder() *recorder {
+	return &recorder{mu: &sync.Mutex{}}
+}
+
+func (r *recorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) component(name string, dependsOn ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: dependsOn,
+		Start: func(ctx context.Context) error {
+			r.record(`start ` + name)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			r.record(`stop ` + name)
+			return nil
+		},
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		components func(r *recorder) []Component
+		expected   []string
+	}{
+		{
+			name: `registration order`,
+			components: func(r *recorder) []Component {
+				return []Component{r.component(`mysql`), r.component(`redis`)}
+			},
+			expected: []string{`start mysql`, `start redis`, `stop redis`, `stop mysql`},
+		},
+		{
+			name: `dependencies first`,
+			components: func(r *recorder) []Component {
+				return []Component{r.component(`consumer`, `mysql`, `server`), r.component(`server`, `mysql`), r.component(`mysql`)}
+			},
+			expected: []string{`start mysql`, `start server`, `start consumer`, `stop consumer`, `stop server`, `stop mysql`},
+		},
+		{
+			name: `instances`,
+			components: func(r *recorder) []Component {
+				return []Component{r.component(`consumer`, `mysql`, `unknown`), r.component(`mysql:reports`), r.component(`mysql`)}
+			},
+			expected: []string{`start mysql:reports`, `start mysql`, `start consumer`, `stop consumer`, `stop mysql`, `stop mysql:reports`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newRecorder()
+			l := New()
+			for _, c := range test.components(r) {
+				l.Register(c)
+			}
+
+			if err := l.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			//running components are not started twice
+			if err := l.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(r.calls, test.expected) {
+				t.Errorf(`expected %v, got %v`, test.expected, r.calls)
+			}
+		})
+	}
+}
+
+func TestCycle(t *testing.T) {
+	r := newRecorder()
+	l := New()
+	l.Register(r.component(`a`, `b`))
+	l.Register(r.component(`b`, `c`))
+	l.Register(r.component(`c`, `a`))
+
+	err := l.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `a -> b -> c -> a`) {
+		t.Fatalf(`expected the cycle to be reported, got %v`, err)
+	}
+	if len(r.calls) > 0 {
+		t.Errorf(`expected nothing to start, got %v`, r.calls)
+	}
+}
+
+func TestStartFailure(t *testing.T) {
+	r := newRecorder()
+	l := New()
+	l.Register(r.component(`mysql`))
+	l.Register(Component{
+		Name:      `consumer`,
+		DependsOn: []string{`mysql`},
+		Start: func(ctx context.Context) error {
+			return errors.New(`broker unreachable`)
+		},
+	})
+	l.Register(r.component(`server`, `consumer`))
+
+	err := l.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `broker unreachable`) {
+		t.Fatalf(`expected the start error, got %v`, err)
+	}
+
+	expected := []string{`start mysql`, `stop mysql`}
+	if !reflect.DeepEqual(r.calls, expected) {
+		t.Errorf(`expected the started components to be stopped, got %v`, r.calls)
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	stopped := make(chan struct{})
+
+	l := New()
+	l.Register(Component{
+		Name: `mysql`,
+		Stop: func(ctx context.Context) error {
+			close(stopped)
+			return nil
+		},
+	})
+	l.Register(Component{
+		Name:      `consumer`,
+		DependsOn: []string{`mysql`},
+		Stop: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := l.Stop(ctx)
+	if err == nil || !strings.Contains(err.Error(), `consumer did not stop before the deadline`) {
+		t.Errorf(`expected the stuck component to be reported, got %v`, err)
+	}
+
+	//the stuck component is left behind, the others are still stopped
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error(`expected mysql to be stopped`)
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := newRecorder()
+	l := New()
+	l.Register(r.component(`mysql`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- l.Run(ctx)
+	}()
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- l.Wait()
+	}()
+
+	cancel()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal(`expected Run to return once ctx is cancelled`)
+	}
+
+	if err := <-waited; err != nil {
+		t.Fatal(err)
+	}
+
+	//shutting down again does nothing
+	if err := l.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.calls, []string{`start mysql`, `stop mysql`}) {
+		t.Errorf(`expected one start and one stop, got %v`, r.calls)
+	}
+}
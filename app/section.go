@@ -0,0 +1,130 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/log"
+)
+
+//Section A named configuration section of a service, loaded from config/<name> through the
+//same pipeline as the built-in configurations (defaults, file, env, secrets and validation)
+type Section struct {
+	Name string
+	//Config Pointer to the struct the section is decoded into at Init
+	Config interface{}
+	//Validate Checks run on top of the validate tags, on load and on every reload
+	Validate config.Validator
+	//OnReload Called with a freshly loaded and validated value each time the source changes,
+	//after it is copied into Config. Sections without a hook are not watched
+	OnReload func(config interface{})
+	//AllowUnknownFields Ignore keys of the file Config does not have instead of failing to load
+	AllowUnknownFields bool
+}
+
+//Path Configuration path of the section
+func (s Section) Path() string {
+	return `config/` + s.Name
+}
+
+var (
+	sectionsMu = &sync.RWMutex{}
+	sections   = make([]*registeredSection, 0)
+)
+
+type registeredSection struct {
+	Section
+	current interface{}
+}
+
+//Register Add a configuration section loaded by Init. The type of the section is registered
+//with config.RegisterType so it is covered by schemas, linting and dumps
+func Register(section Section) {
+	if reflect.TypeOf(section.Config) == nil || reflect.TypeOf(section.Config).Kind() != reflect.Ptr {
+		panic(fmt.Sprintf(`app: config of section %s should be a pointer to a struct, got %T`, section.Name, section.Config))
+	}
+
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+
+	for _, s := range sections {
+		if s.Name == section.Name {
+			panic(`app: section ` + section.Name + ` is already registered`)
+		}
+	}
+
+	sections = append(sections, &registeredSection{Section: section, current: section.Config})
+	config.RegisterType(section.Path(), section.Config)
+	if section.AllowUnknownFields {
+		config.AllowUnknownFields(section.Path())
+	}
+}
+
+//Get Current value of a section, the latest reloaded one for watched sections
+func Get(name string) (interface{}, bool) {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+
+	for _, s := range sections {
+		if s.Name == name {
+			return s.current, true
+		}
+	}
+
+	return nil, false
+}
+
+//Init Load every registered section through config.DefaultErrorConfigurator and watch the ones
+//with a reload hook. Problems of all sections are reported together before stopping the application
+func Init() {
+	if err := Load(config.DefaultErrorConfigurator); err != nil {
+		log.Fatal(`Cannot load app configuration`, err)
+	}
+}
+
+//Load Load every registered section through a configurator
+func Load(configurator config.ErrorConfigurator) error {
+	sectionsMu.RLock()
+	registered := append([]*registeredSection{}, sections...)
+	sectionsMu.RUnlock()
+
+	errs := make(config.Errors, 0)
+	for _, s := range registered {
+		errs = errs.Append(load(configurator, s))
+	}
+
+	return errs.Err()
+}
+
+func load(configurator config.ErrorConfigurator, s *registeredSection) error {
+	validators := make([]config.Validator, 0)
+	if s.Validate != nil {
+		validators = append(validators, s.Validate)
+	}
+
+	if err := configurator.Load(s.Path(), s.Config, validators...); err != nil {
+		return err
+	}
+
+	if s.OnReload == nil {
+		return nil
+	}
+
+	_, err := configurator.Watch(s.Path(), s.Config, s.Validate, func(c interface{}) {
+		sectionsMu.Lock()
+		reflect.ValueOf(s.Config).Elem().Set(reflect.ValueOf(c).Elem())
+		s.current = c
+		sectionsMu.Unlock()
+
+		s.OnReload(c)
+	})
+	if errors.Is(err, config.ErrWatchNotSupported) {
+		log.Info(`app section ` + s.Name + ` will not be reloaded, source cannot be watched`)
+		return nil
+	}
+
+	return err
+}
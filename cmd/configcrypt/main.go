@@ -0,0 +1,113 @@
+//configcrypt Encrypt values of configuration files, decrypted transparently by the config loaders
+//
+//	configcrypt -genkey                                 print a new master key
+//	configcrypt -encrypt config/database.yaml           encrypt password, secret and token values in place
+//	configcrypt -encrypt -keys 'password|host' file     encrypt the values whose key matches a regexp
+//	configcrypt -decrypt config/database.yaml           print the decrypted document
+//	configcrypt -rotate config/database.yaml            re-encrypt the values with a new data key
+//	configcrypt -rotate -new-key-file new.key file      and wrap it with a new master key
+//
+//The master key is read from CONFIG_KEY or from the file named by CONFIG_KEY_FILE
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/danakum/go-util/config"
+)
+
+func main() {
+	genkey := flag.Bool(`genkey`, false, `print a new base64 encoded master key`)
+	encrypt := flag.Bool(`encrypt`, false, `encrypt the matching values of the files in place`)
+	decrypt := flag.Bool(`decrypt`, false, `print the decrypted files`)
+	rotate := flag.Bool(`rotate`, false, `re-encrypt the values of the files in place with a new data key`)
+	keys := flag.String(`keys`, `(?i)^(password|passwd|secret|token)$`, `regexp of the keys whose values -encrypt encrypts`)
+	newKeyFile := flag.String(`new-key-file`, ``, `master key file -rotate wraps the new data key with, the current key by default`)
+	flag.Parse()
+
+	if *genkey {
+		key, err := config.GenerateKey()
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	if !*encrypt && !*decrypt && !*rotate {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	masterKey, err := config.MasterKey()
+	if err != nil {
+		fail(err)
+	}
+
+	match, err := regexp.Compile(*keys)
+	if err != nil {
+		fail(err)
+	}
+
+	newMasterKey := masterKey
+	if *newKeyFile != `` {
+		byt, err := ioutil.ReadFile(*newKeyFile)
+		if err != nil {
+			fail(err)
+		}
+
+		if newMasterKey, err = config.ParseKey(string(byt)); err != nil {
+			fail(err)
+		}
+	}
+
+	for _, file := range flag.Args() {
+		byt, err := ioutil.ReadFile(file)
+		if err != nil {
+			fail(err)
+		}
+
+		switch {
+		case *decrypt:
+			plain, err := config.DecryptDocument(byt, masterKey)
+			if err != nil {
+				fail(fmt.Errorf(`%s : %w`, file, err))
+			}
+			os.Stdout.Write(plain)
+			continue
+		case *encrypt:
+			byt, err = config.EncryptDocument(byt, masterKey, func(path []string) bool {
+				return match.MatchString(path[len(path)-1])
+			})
+		case *rotate:
+			byt, err = config.RotateDocument(byt, masterKey, newMasterKey)
+		}
+		if err != nil {
+			fail(fmt.Errorf(`%s : %w`, file, err))
+		}
+
+		if err := writeFile(file, byt); err != nil {
+			fail(err)
+		}
+		fmt.Println(`configcrypt:`, file, `written`)
+	}
+}
+
+//writeFile Replace a file keeping its permissions
+func writeFile(file string, byt []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, byt, info.Mode().Perm())
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, `configcrypt:`, err)
+	os.Exit(1)
+}
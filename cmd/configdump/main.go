@@ -0,0 +1,23 @@
+//configdump Print the effective configuration of every registered type, as loaded from files,
+//Zookeeper and the environment, with secrets redacted
+//
+//	configdump -format json
+//
+//Services dump their own registrations with a command of their own running config.DumpCommand
+package main
+
+import (
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/mongo"
+	_ "github.com/danakum/go-util/mqtt"
+	_ "github.com/danakum/go-util/mysql"
+	_ "github.com/danakum/go-util/postgre"
+	_ "github.com/danakum/go-util/redis"
+)
+
+func main() {
+	config.DumpCommand(&config.Configurations{
+		&config.AppConf,
+		mongo.Config{},
+	})
+}
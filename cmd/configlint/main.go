@@ -0,0 +1,171 @@
+//configlint Validate configuration files against the registered configuration types
+//before they reach Configurator.Load
+//
+//	configlint -dir config        validate every yaml and json file of a directory
+//	configlint -schema schemas    write the JSON Schema of every registered type
+//
+//Files are matched to types by name, config/<name>.<profile>.yaml files are validated
+//merged over their base file. The exit code is 1 when a file is invalid
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danakum/go-util/config"
+	_ "github.com/danakum/go-util/mongo"
+	_ "github.com/danakum/go-util/mqtt"
+	_ "github.com/danakum/go-util/mysql"
+	_ "github.com/danakum/go-util/postgre"
+	_ "github.com/danakum/go-util/redis"
+)
+
+var extensions = []string{`.yaml`, `.yml`, `.json`}
+
+func main() {
+	dir := flag.String(`dir`, `config`, `directory of configuration files to validate`)
+	schemaDir := flag.String(`schema`, ``, `write the JSON Schema of every registered type into this directory`)
+	flag.Parse()
+
+	if *schemaDir != `` {
+		if err := writeSchemas(*schemaDir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	failures, err := lintDir(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			fmt.Fprintln(os.Stderr, failure)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Println(`configlint: all configuration files are valid`)
+}
+
+func writeSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, path := range config.RegisteredPaths() {
+		typ, _ := config.RegisteredType(path)
+		byt, err := json.MarshalIndent(config.Schema(path, typ), ``, `  `)
+		if err != nil {
+			return err
+		}
+
+		file := filepath.Join(dir, filepath.Base(path)+`.schema.json`)
+		if err := ioutil.WriteFile(file, append(byt, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Println(`configlint: schema written to`, file)
+	}
+
+	return nil
+}
+
+//lintDir Validate every file of dir belonging to a registered type
+func lintDir(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, path := range config.RegisteredPaths() {
+		names[filepath.Base(path)] = path
+	}
+
+	failures := make([]string, 0)
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || !isConfigFile(ext) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(file.Name(), ext), `.`, 2)
+		path, ok := names[parts[0]]
+		if !ok {
+			continue
+		}
+
+		byt, err := readMerged(dir, parts, ext)
+		if err == nil {
+			err = config.Lint(path, byt)
+		}
+
+		if err != nil {
+			failures = append(failures, failureLines(filepath.Join(dir, file.Name()), err)...)
+		}
+	}
+
+	sort.Strings(failures)
+	return failures, nil
+}
+
+//readMerged Read a file, profile files (name.profile.ext) are merged over their base file.
+//Encrypted files are decrypted with the master key of the environment
+func readMerged(dir string, parts []string, ext string) ([]byte, error) {
+	byt, err := readFile(filepath.Join(dir, strings.Join(parts, `.`)+ext))
+	if err != nil || len(parts) < 2 {
+		return byt, err
+	}
+
+	base, err := readFile(filepath.Join(dir, parts[0]+ext))
+	if os.IsNotExist(err) {
+		return byt, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return config.MergeYaml(base, byt)
+}
+
+func readFile(file string) ([]byte, error) {
+	byt, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Decrypt(byt)
+}
+
+func failureLines(file string, err error) []string {
+	errs, ok := err.(config.Errors)
+	if !ok {
+		return []string{file + ` : ` + err.Error()}
+	}
+
+	lines := make([]string, 0, len(errs))
+	for _, err := range errs {
+		lines = append(lines, file+` : `+err.Error())
+	}
+
+	return lines
+}
+
+func isConfigFile(ext string) bool {
+	for _, e := range extensions {
+		if e == ext {
+			return true
+		}
+	}
+
+	return false
+}
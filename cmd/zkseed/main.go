@@ -0,0 +1,176 @@
+//zkseed Write a local config directory to the znodes read by config.ZookeeperLoader
+//
+//	zkseed -dir config                   show the difference with zookeeper
+//	zkseed -dir config -profile prod     merge config/<name>.prod.yaml files first (or APP_PROFILE)
+//	zkseed -dir config -apply            create or update the nodes in one transaction
+//	zkseed -history config/database      list the revisions of a node
+//	zkseed -history config/database -rollback history-0000000003
+//
+//config/<name>.yaml is written as json to /<ZK_CONFIG_PATH>config/<name>, the node the
+//loader reads for the path config/<name>
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/zookeeper"
+	"gopkg.in/yaml.v2"
+)
+
+func main() {
+	dir := flag.String(`dir`, `config`, `local configuration directory`)
+	hosts := flag.String(`hosts`, ``, `comma separated zookeeper hosts, overrides ZK_HOSTS and config/zookeeper.yaml`)
+	prefix := flag.String(`prefix`, os.Getenv(`ZK_CONFIG_PATH`), `prefix of the config nodes, same as ZK_CONFIG_PATH`)
+	apply := flag.Bool(`apply`, false, `write the changes, otherwise only show them`)
+	author := flag.String(`author`, os.Getenv(`USER`), `author recorded in the history of written nodes`)
+	history := flag.String(`history`, ``, `list the revisions of a config path (config/database) instead of seeding`)
+	rollback := flag.String(`rollback`, ``, `revision of the -history path to publish again`)
+	flag.Parse()
+
+	if *hosts != `` {
+		os.Setenv(`ZK_HOSTS`, *hosts)
+	}
+
+	conn, err := zookeeper.Client()
+	if err != nil {
+		fail(err)
+	}
+	defer zookeeper.Close()
+
+	if *history != `` {
+		showHistory(conn, zookeeper.Path(*prefix+*history), *rollback, *author)
+		return
+	}
+
+	nodes, err := configNodes(*dir, *prefix, config.Profile())
+	if err != nil {
+		fail(err)
+	}
+
+	changes, err := zookeeper.Plan(conn, nodes)
+	if err != nil {
+		fail(err)
+	}
+
+	if len(changes) < 1 {
+		fmt.Println(`zkseed: zookeeper is up to date`)
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Println(change.Diff())
+	}
+
+	if !*apply {
+		fmt.Printf("zkseed: %d nodes differ, run with -apply to write them\n", len(changes))
+		return
+	}
+
+	if err := zookeeper.Apply(conn, changes, *author); err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("zkseed: %d nodes written\n", len(changes))
+}
+
+//configNodes Json documents of the yaml and json files of dir, keyed by znode path
+func configNodes(dir string, prefix string, profile string) (map[string][]byte, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string][]byte)
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		name := strings.TrimSuffix(file.Name(), ext)
+		if file.IsDir() || (ext != `.yaml` && ext != `.yml` && ext != `.json`) || strings.Contains(name, `.`) {
+			continue
+		}
+
+		byt, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if profile != `` {
+			overlay, err := ioutil.ReadFile(filepath.Join(dir, name+`.`+profile+ext))
+			if err == nil && (config.IsEncrypted(byt) || config.IsEncrypted(overlay)) {
+				//the merged document would keep the data key of only one of the files
+				return nil, fmt.Errorf(`cannot merge encrypted file %s with its %s profile`, file.Name(), profile)
+			}
+			if err == nil {
+				byt, err = config.MergeYaml(byt, overlay)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+
+		var document interface{}
+		if err := yaml.Unmarshal(byt, &document); err != nil {
+			return nil, fmt.Errorf(`cannot decode %s : %w`, file.Name(), err)
+		}
+
+		data, err := json.Marshal(jsonValue(document))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot encode %s : %w`, file.Name(), err)
+		}
+
+		nodes[zookeeper.Path(prefix+`config/`+name)] = data
+	}
+
+	return nodes, nil
+}
+
+//jsonValue Convert the maps decoded from yaml into maps json can encode
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	}
+
+	return v
+}
+
+//showHistory List the revisions of a node, or roll it back to one of them
+func showHistory(conn zookeeper.Conn, node string, rollback string, author string) {
+	if rollback != `` {
+		if err := zookeeper.Rollback(conn, node, rollback, author); err != nil {
+			fail(err)
+		}
+		fmt.Printf("zkseed: %s rolled back to %s\n", node, rollback)
+		return
+	}
+
+	revisions, err := zookeeper.History(conn, node)
+	if err != nil {
+		fail(err)
+	}
+
+	for _, revision := range revisions {
+		fmt.Printf("%s\t%s\t%s\t%s\n", revision.ID, revision.Time.Format(time.RFC3339), revision.Author, revision.Comment)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, `zkseed:`, err)
+	os.Exit(1)
+}
@@ -1,28 +1,23 @@
 package config
 
 import (
-	"flag"
 	"github.com/danakum/go-util/log"
-	"os"
-	"strconv"
 	"time"
 )
 
 type AppConfig struct {
-	Port     int    `yaml:"port" json:"port"`
-	Debug    bool   `yaml:"debug" json:"debug"`
-	Timezone string `yaml:"timezone" json:"timezone"`
-	Location *time.Location
+	Port            int            `yaml:"port" json:"port" validate:"min=0,max=65535"`
+	Debug           bool           `yaml:"debug" json:"debug"`
+	Timezone        string         `yaml:"timezone" json:"timezone" validate:"required"`
+	ShutdownTimeout time.Duration  `yaml:"shutdown_timeout" json:"shutdown_timeout" default:"30s"` //Deadline to stop every component on SIGINT or SIGTERM
+	Profile         string         `yaml:"-" json:"-"`                                             //Active configuration profile, see Profile()
+	Location        *time.Location `yaml:"-" json:"-"`
 }
 
 var (
 	AppConf AppConfig
 )
 
-func init() {
-	flag.Parse()
-}
-
 func (c *AppConfig) Register() {
 	ParseAppConfig()
 }
@@ -30,21 +25,11 @@ func (c *AppConfig) Register() {
 //ParseAppConfig Parse application configs
 func ParseAppConfig() {
 
-	DefaultConfigurator.Load(`config/app`, &AppConf, func(config interface{}) {
-		conf, _ := config.(*AppConfig)
-
-		if conf.Timezone == `` {
-			log.Fatal(`App timezone cannot be empty`)
-		}
-	})
-
-	if os.Getenv(`APP_PORT`) != `` {
-		if port, err := strconv.Atoi(os.Getenv(`APP_PORT`)); err == nil {
-			AppConf.Port = port
-		}
-
+	if err := DefaultErrorConfigurator.Load(`config/app`, &AppConf); err != nil {
+		log.Fatal(err)
 	}
 
+	AppConf.Profile = Profile()
 	setDefaultTimeLocation(AppConf.Timezone)
 }
 
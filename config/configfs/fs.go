@@ -0,0 +1,114 @@
+package configfs
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	mu       = &sync.RWMutex{}
+	root     = `config`
+	defaults fs.FS
+	hooks    []func()
+)
+
+func init() {
+	if os.Getenv(`CONFIG_ROOT`) != `` {
+		root = os.Getenv(`CONFIG_ROOT`)
+	}
+}
+
+//SetRoot Directory configuration files are read from. Defaults to config relative to the
+//working directory, or to CONFIG_ROOT when it is set
+func SetRoot(dir string) {
+	mu.Lock()
+	root = dir
+	mu.Unlock()
+
+	changed()
+}
+
+func Root() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return root
+}
+
+//SetDefaults Files used when a configuration file does not exist under the root, with names
+//relative to the root (database.yaml). ex: an embed.FS narrowed with fs.Sub(files, `config`)
+func SetDefaults(fsys fs.FS) {
+	mu.Lock()
+	defaults = fsys
+	mu.Unlock()
+
+	changed()
+}
+
+//OnChange Call fn whenever SetRoot or SetDefaults change where configuration files are read from,
+//for files read once at startup (ex: config/logger.yaml by the log package)
+func OnChange(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	hooks = append(hooks, fn)
+}
+
+func changed() {
+	mu.RLock()
+	fns := append([]func(){}, hooks...)
+	mu.RUnlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+//Path Location of a configuration path on disk, paths under config/ are moved under the root
+func Path(path string) string {
+	name, ok := relative(path)
+	if !ok {
+		return path
+	}
+
+	return filepath.Join(Root(), name)
+}
+
+//ReadFile Read a configuration file from the root, falling back to the defaults
+func ReadFile(path string) ([]byte, error) {
+	byt, err := ioutil.ReadFile(Path(path))
+	if !errors.Is(err, fs.ErrNotExist) {
+		return byt, err
+	}
+
+	mu.RLock()
+	fsys := defaults
+	mu.RUnlock()
+
+	name, ok := relative(path)
+	if fsys == nil || !ok {
+		return nil, err
+	}
+
+	byt, defaultErr := fs.ReadFile(fsys, name)
+	if defaultErr != nil {
+		return nil, err
+	}
+
+	return byt, nil
+}
+
+//relative Name of a config/ path relative to the root
+func relative(path string) (string, bool) {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, `config/`) {
+		return ``, false
+	}
+
+	return strings.TrimPrefix(path, `config/`), true
+}
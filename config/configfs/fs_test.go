@@ -0,0 +1,94 @@
+package configfs
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func setTestRoot(t *testing.T, files map[string]string, defaultFiles fstest.MapFS) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previousRoot, previousDefaults := Root(), defaults
+	t.Cleanup(func() {
+		SetRoot(previousRoot)
+		SetDefaults(previousDefaults)
+	})
+
+	SetRoot(dir)
+	SetDefaults(defaultFiles)
+}
+
+func TestReadFile(t *testing.T) {
+	setTestRoot(t, map[string]string{
+		`database.yaml`: `host: db`,
+	}, fstest.MapFS{
+		`database.yaml`: {Data: []byte(`host: default`)},
+		`redis.yaml`:    {Data: []byte(`host: cache`)},
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+		missing  bool
+	}{
+		{path: `config/database.yaml`, expected: `host: db`},
+		{path: `config/redis.yaml`, expected: `host: cache`},
+		{path: `config/missing.yaml`, missing: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			byt, err := ReadFile(test.path)
+			if test.missing {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf(`expected a not exist error, got %v`, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(byt) != test.expected {
+				t.Errorf(`expected %q, got %q`, test.expected, byt)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	setTestRoot(t, nil, nil)
+
+	if got := Path(`config/database.yaml`); got != filepath.Join(Root(), `database.yaml`) {
+		t.Errorf(`expected config/ to be moved under the root, got %s`, got)
+	}
+
+	if got := Path(`/etc/app/database.yaml`); got != `/etc/app/database.yaml` {
+		t.Errorf(`expected paths outside config/ to be kept, got %s`, got)
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	calls := 0
+	OnChange(func() {
+		calls++
+	})
+
+	setTestRoot(t, nil, nil)
+
+	//the root and the defaults are both set
+	if calls != 2 {
+		t.Errorf(`expected 2 changes, got %d`, calls)
+	}
+}
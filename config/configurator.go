@@ -1,8 +1,15 @@
 package config
 
-import "github.com/danakum/go-util/log"
+import (
+	"fmt"
+	"github.com/danakum/go-util/log"
+	"os"
+)
 
-var DefaultConfigurator Configurator
+var (
+	DefaultConfigurator      Configurator
+	DefaultErrorConfigurator ErrorConfigurator
+)
 
 type Loader interface {
 	Load(path string, i interface{}) error
@@ -10,29 +17,135 @@ type Loader interface {
 
 type Configurator interface {
 	Load(path string, i interface{}, validator func(config interface{}))
+	Watch(path string, i interface{}, validator Validator, callback func(config interface{})) (stop func(), err error)
 }
 
-type defaultConfigurator struct {
+//ErrorConfigurator Configurator which reports loading and validation problems to the caller
+type ErrorConfigurator interface {
+	Load(path string, i interface{}, validators ...Validator) error
+	Watch(path string, i interface{}, validator Validator, callback func(config interface{})) (stop func(), err error)
+}
+
+//Validator Check a configuration and report problems instead of stopping the application
+type Validator func(config interface{}) error
+
+type errorConfigurator struct {
 	loader Loader
 }
 
+func NewErrorConfigurator(loader Loader) *errorConfigurator {
+	return &errorConfigurator{
+		loader: loader,
+	}
+}
+
+//Load Load a configuration, resolve its secret references and validate it. The validate tags
+//are checked first and every validator runs even when a previous one failed, the returned
+//Errors lists all the failures
+func (c *errorConfigurator) Load(path string, i interface{}, validators ...Validator) error {
+	if err := c.loader.Load(path, i); err != nil {
+		return fmt.Errorf(`cannot load configuration %s : %w`, path, err)
+	}
+
+	if err := ResolveSecrets(path, i); err != nil {
+		return err
+	}
+
+	errs := make(Errors, 0).Append(Validate(path, i))
+	for _, validator := range validators {
+		errs = errs.Append(validator(i))
+	}
+
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	log.Info(`configuration ` + path + ` loaded`)
+	return nil
+}
+
+//defaultConfigurator Stops the application on the first configuration problem
+type defaultConfigurator struct {
+	*errorConfigurator
+}
+
 func NewConfigurator(loader Loader) *defaultConfigurator {
 	return &defaultConfigurator{
-		loader: loader,
+		errorConfigurator: NewErrorConfigurator(loader),
 	}
 }
 
 func (c *defaultConfigurator) Load(path string, i interface{}, validator func(config interface{})) {
-	err := c.loader.Load(path, i)
+	err := c.errorConfigurator.Load(path, i, func(config interface{}) error {
+		validator(config)
+		return nil
+	})
 	if err != nil {
 		log.Fatal(`cannot load configuration `+path, err)
 	}
-	validator(i)
-	log.Info(`configuration ` + path + ` loaded`)
 }
 
+//init Default configurations are built from struct tag defaults, overridden by config/<name>.yaml,
+//then by the files of CONFIG_DIR/<name> when set (mounted kubernetes secrets), then by environment
+//variables and then by command line flags.
+//
+//Environment variables are prefixed with CONFIG_ENV_PREFIX, CONFIG by default (CONFIG_DATABASE_WRITE_HOST),
+//so the variables kubernetes sets for services (REDIS_PORT=tcp://...) are not read as configuration.
+//Set CONFIG_ENV_PREFIX to an empty value to read unprefixed variables. APP_* variables (APP_PORT)
+//are read for config/app whatever the prefix
 func init() {
-	DefaultConfigurator = NewConfigurator(NewYmlFileLoader())
+	layers := []Layer{
+		{Name: `defaults`, Loader: NewDefaultLoader()},
+		{Name: `file`, Loader: NewYmlFileLoader()},
+	}
+
+	if dir := os.Getenv(`CONFIG_DIR`); dir != `` {
+		layers = append(layers, Layer{Name: `dir`, Loader: NewDirLoader(dir), Optional: true})
+	}
+
+	prefix, ok := os.LookupEnv(`CONFIG_ENV_PREFIX`)
+	if !ok {
+		prefix = `CONFIG`
+	}
+
+	if prefix != `` {
+		layers = append(layers, Layer{Name: `env`, Loader: appEnvLoader{Loader: NewEnvLoader(``)}})
+	}
+
+	layers = append(layers,
+		Layer{Name: `env`, Loader: NewEnvLoader(prefix)},
+		Layer{Name: `flags`, Loader: DefaultFlagLoader},
+	)
+
+	configurator := NewConfigurator(NewLayeredLoader(layers...))
+
+	DefaultConfigurator = configurator
+	DefaultErrorConfigurator = configurator.errorConfigurator
+}
+
+//appEnvLoader Read unprefixed environment variables for config/app only
+type appEnvLoader struct {
+	Loader
+}
+
+func (l appEnvLoader) Load(path string, i interface{}) error {
+	if path != `config/app` {
+		return nil
+	}
+
+	return l.Loader.Load(path, i)
+}
+
+//Sources Name of the layer each field of a configuration loaded by the default configurators came from,
+//see LayeredLoader.Sources. Empty when DefaultErrorConfigurator was replaced by another configurator
+func Sources(path string) map[string]string {
+	if c, ok := DefaultErrorConfigurator.(*errorConfigurator); ok {
+		if layered, ok := c.loader.(*LayeredLoader); ok {
+			return layered.Sources(path)
+		}
+	}
+
+	return make(map[string]string)
 }
 
 type Config interface {
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//ConsulWatchWait Longest time a consul blocking query waits for a change before it is renewed
+var ConsulWatchWait = `5m`
+
+//ConsulLoader Read configurations from consul KV keys through its http api (/v1/kv).
+//Keys hold json or yaml documents, as zookeeper nodes do
+type ConsulLoader struct {
+	client *kvClient
+}
+
+func NewConsulLoader(options KVOptions) (*ConsulLoader, error) {
+	client, err := newKVClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsulLoader{
+		client: client,
+	}, nil
+}
+
+func (l *ConsulLoader) Load(path string, i interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.client.options.Timeout)
+	defer cancel()
+
+	value, _, err := l.get(ctx, path, 0, ``)
+	if err != nil {
+		return err
+	}
+
+	if value, err = DecryptConfig(path, value); err != nil {
+		return fmt.Errorf(`consul cannot decrypt key %s : %w`, l.client.key(path), err)
+	}
+
+	if err := decodeDocument(value, i, l.client.options.Strict); err != nil {
+		return fmt.Errorf(`consul cannot decode key %s : %w`, l.client.key(path), err)
+	}
+
+	return nil
+}
+
+//get Raw value of the key of a path and its X-Consul-Index. A non zero index makes a
+//blocking query which returns once the index moves past it or wait elapses
+func (l *ConsulLoader) get(ctx context.Context, path string, index uint64, wait string) ([]byte, uint64, error) {
+	key := l.client.key(path)
+
+	query := url.Values{}
+	query.Set(`raw`, ``)
+	if l.client.options.Datacenter != `` {
+		query.Set(`dc`, l.client.options.Datacenter)
+	}
+	if index > 0 {
+		query.Set(`index`, strconv.FormatUint(index, 10))
+		query.Set(`wait`, wait)
+	}
+
+	res, err := l.client.do(ctx, func(endpoint string) (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, endpoint+`/v1/kv/`+strings.TrimLeft(key, `/`)+`?`+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if l.client.options.Token != `` {
+			req.Header.Set(`X-Consul-Token`, l.client.options.Token)
+		}
+
+		return req, nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf(`consul cannot read key %s : %w`, key, err)
+	}
+	defer res.Body.Close()
+
+	next, _ := strconv.ParseUint(res.Header.Get(`X-Consul-Index`), 10, 64)
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, next, fmt.Errorf(`consul cannot read key %s : %w`, key, ErrKeyNotFound)
+	default:
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, next, fmt.Errorf(`consul cannot read key %s : responded %s : %s`, key, res.Status, bytes.TrimSpace(msg))
+	}
+
+	value, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, next, fmt.Errorf(`consul cannot read key %s : %w`, key, err)
+	}
+
+	return value, next, nil
+}
+
+//Watch Notify on every change of the key behind a path, using blocking queries on its index.
+//A failed query is retried from the last seen index, so no update is missed
+func (l *ConsulLoader) Watch(path string, changed func()) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.client.options.Timeout)
+	defer cancel()
+
+	_, index, err := l.get(ctx, path, 0, ``)
+	if err != nil {
+		return nil, err
+	}
+
+	wait := ConsulWatchWait
+
+	return watchLoop(`consul key `+l.client.key(path), func(stop <-chan struct{}) error {
+		ctx, cancel := stopContext(stop)
+		defer cancel()
+
+		for {
+			_, next, err := l.get(ctx, path, index, wait)
+			if err != nil && !isNotFound(err) {
+				return err
+			}
+
+			switch {
+			case next == 0:
+				return fmt.Errorf(`missing X-Consul-Index`)
+			case next != index:
+				//the index can also go backwards, after a snapshot restore
+				index = next
+				changed()
+			}
+		}
+	}), nil
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//fakeConsul Stand-in of the consul kv http api answering blocking queries
+type fakeConsul struct {
+	mu     sync.Mutex
+	index  uint64
+	values map[string]string
+	notify chan struct{}
+	//indexes Index of every blocking query
+	indexes []uint64
+	//failures Blocking queries to answer with an error before serving them again
+	failures int
+}
+
+func newFakeConsul() *fakeConsul {
+	return &fakeConsul{
+		index:  1,
+		values: make(map[string]string),
+		notify: make(chan struct{}),
+	}
+}
+
+func (c *fakeConsul) set(key string, value string, index uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.index = index
+	if value == `` {
+		delete(c.values, key)
+	} else {
+		c.values[key] = value
+	}
+
+	close(c.notify)
+	c.notify = make(chan struct{})
+}
+
+func (c *fakeConsul) fail(failures int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = failures
+}
+
+func (c *fakeConsul) queries() []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]uint64{}, c.indexes...)
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(`X-Consul-Token`) != `secret-token` {
+		http.Error(w, `ACL not found`, http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, `/v1/kv/`)
+	index, _ := strconv.ParseUint(r.URL.Query().Get(`index`), 10, 64)
+	wait, _ := time.ParseDuration(r.URL.Query().Get(`wait`))
+
+	c.mu.Lock()
+	notify := c.notify
+	if index > 0 {
+		c.indexes = append(c.indexes, index)
+
+		if c.failures > 0 {
+			c.failures--
+			c.mu.Unlock()
+			http.Error(w, `rpc error`, http.StatusInternalServerError)
+			return
+		}
+	}
+	blocking := index > 0 && index == c.index
+	c.mu.Unlock()
+
+	if blocking {
+		select {
+		case <-notify:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	c.mu.Lock()
+	value, ok := c.values[key]
+	w.Header().Set(`X-Consul-Index`, strconv.FormatUint(c.index, 10))
+	c.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_, _ = w.Write([]byte(value))
+}
+
+func newTestConsulLoader(t *testing.T, consul *fakeConsul) *ConsulLoader {
+	//blocking queries without a change return quickly
+	previous := ConsulWatchWait
+	ConsulWatchWait = `100ms`
+	t.Cleanup(func() {
+		ConsulWatchWait = previous
+	})
+
+	server := httptest.NewServer(consul)
+	t.Cleanup(server.Close)
+
+	loader, err := NewConsulLoader(KVOptions{
+		Endpoints: []string{server.URL},
+		Prefix:    `services/orders/`,
+		Token:     `secret-token`,
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loader
+}
+
+func TestConsulLoad(t *testing.T) {
+	consul := newFakeConsul()
+	consul.set(`services/orders/config/database`, "host: db\nport: 5432\n", 2)
+	loader := newTestConsulLoader(t, consul)
+
+	c := kvTestConfig{}
+	if err := loader.Load(`config/database`, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c != (kvTestConfig{Host: `db`, Port: 5432}) {
+		t.Errorf(`expected db:5432, got %+v`, c)
+	}
+
+	if err := loader.Load(`config/missing`, &kvTestConfig{}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf(`expected key not found, got %v`, err)
+	}
+}
+
+func TestConsulWatchResumesFromLastIndex(t *testing.T) {
+	consul := newFakeConsul()
+	consul.set(`services/orders/config/database`, `{"host":"db"}`, 5)
+	loader := newTestConsulLoader(t, consul)
+
+	changes := make(chan struct{}, 10)
+	stop, err := loader.Watch(`config/database`, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	consul.set(`services/orders/config/database`, `{"host":"db2"}`, 7)
+	waitChanged(t, changes, `expected a change at index 7`)
+
+	//the next query fails, the change made meanwhile is seen from the last index
+	consul.fail(1)
+	time.Sleep(200 * time.Millisecond)
+	consul.set(`services/orders/config/database`, `{"host":"db3"}`, 9)
+	waitChanged(t, changes, `expected a change at index 9 after the failed query`)
+
+	//the failed query and its retry are both made on index 7
+	retried := 0
+	for _, index := range consul.queries() {
+		if index != 5 && index != 7 && index != 9 {
+			t.Fatalf(`expected blocking queries on the last seen index only, got %v`, consul.queries())
+		}
+		if index == 7 {
+			retried++
+		}
+	}
+	if retried < 2 {
+		t.Errorf(`expected the failed query to be retried on index 7, got %v`, consul.queries())
+	}
+
+	select {
+	case <-changes:
+		t.Error(`expected no change while the index stays the same`)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestConsulWatchIndexReset(t *testing.T) {
+	consul := newFakeConsul()
+	consul.set(`services/orders/config/database`, `{"host":"db"}`, 50)
+	loader := newTestConsulLoader(t, consul)
+
+	changes := make(chan struct{}, 10)
+	stop, err := loader.Watch(`config/database`, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	//restored from a snapshot, the index goes backwards
+	consul.set(`services/orders/config/database`, `{"host":"restored"}`, 10)
+	waitChanged(t, changes, `expected an index going backwards to be reported as a change`)
+
+	//deleting the key is a change too
+	consul.set(`services/orders/config/database`, ``, 11)
+	waitChanged(t, changes, `expected the deletion to be reported as a change`)
+}
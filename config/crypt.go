@@ -0,0 +1,524 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danakum/go-util/log"
+	"gopkg.in/yaml.v2"
+)
+
+//Encrypted documents keep their structure in clear, only selected values are encrypted, in the
+//spirit of sops. Each value is AES-GCM encrypted with a random data key, bound to its key path:
+//
+//	write:
+//	  host: db.local
+//	  password: ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]
+//	sops:
+//	  encrypted_key: ...   data key encrypted with the master key
+//	  key_id: 1f2e3d4c5b6a7988
+//	  lastmodified: "2021-06-01T10:00:00Z"
+//	  version: 1
+//
+//The master key is 32 bytes, base64 encoded in CONFIG_KEY or in the file named by CONFIG_KEY_FILE.
+//The format is not compatible with the sops tool itself
+
+const (
+	encryptionMetadataKey = `sops`
+	encryptionVersion     = 1
+)
+
+var (
+	ErrNoMasterKey = errors.New(`config: no master key, set CONFIG_KEY or CONFIG_KEY_FILE`)
+
+	encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([^,]*),iv:([^,]+),tag:([^,]+),type:(str|int|float|bool)\]$`)
+)
+
+type encryptionMetadata struct {
+	EncryptedKey string `yaml:"encrypted_key" json:"encrypted_key"`
+	KeyID        string `yaml:"key_id" json:"key_id"`
+	LastModified string `yaml:"lastmodified" json:"lastmodified"`
+	Version      int    `yaml:"version" json:"version"`
+}
+
+//MasterKey Master key of encrypted documents, from CONFIG_KEY or else from the file named by CONFIG_KEY_FILE
+func MasterKey() ([]byte, error) {
+	encoded := os.Getenv(`CONFIG_KEY`)
+	if encoded == `` && os.Getenv(`CONFIG_KEY_FILE`) != `` {
+		byt, err := ioutil.ReadFile(os.Getenv(`CONFIG_KEY_FILE`))
+		if err != nil {
+			return nil, fmt.Errorf(`config: cannot read master key : %w`, err)
+		}
+		encoded = string(byt)
+	}
+
+	if encoded == `` {
+		return nil, ErrNoMasterKey
+	}
+
+	return ParseKey(encoded)
+}
+
+//ParseKey Decode a base64 encoded 32 bytes key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf(`config: invalid master key : %w`, err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf(`config: invalid master key, expected 32 bytes got %d`, len(key))
+	}
+
+	return key, nil
+}
+
+//GenerateKey New random key, base64 encoded
+func GenerateKey() (string, error) {
+	key, err := randomBytes(32)
+	if err != nil {
+		return ``, err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+//IsEncrypted Whether a yaml or json document carries encryption metadata
+func IsEncrypted(byt []byte) bool {
+	if !bytes.Contains(byt, []byte(encryptionMetadataKey)) {
+		return false
+	}
+
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(byt, &doc); err != nil {
+		return false
+	}
+
+	_, _, ok := splitMetadata(doc)
+	return ok
+}
+
+//Decrypt Decrypt a document when it is encrypted, using MasterKey. Plain documents are returned
+//as is. Loaders use DecryptConfig
+func Decrypt(byt []byte) ([]byte, error) {
+	if !IsEncrypted(byt) {
+		return byt, nil
+	}
+
+	key, err := MasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptDocument(byt, key)
+}
+
+//DecryptConfig Decrypt the document of a configuration path like Decrypt. The fields of path
+//whose value was decrypted are reported by IsSecret, so Redact hides them whatever their name
+func DecryptConfig(path string, byt []byte) ([]byte, error) {
+	if !IsEncrypted(byt) {
+		return byt, nil
+	}
+
+	key, err := MasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	plain, fields, err := decryptDocument(byt, key)
+	if err != nil {
+		return nil, err
+	}
+
+	markSecrets(path, fields)
+	return plain, nil
+}
+
+//DecryptDocument Decrypt every value of a document and drop its encryption metadata.
+//The result is yaml, which also decodes documents that were json
+func DecryptDocument(byt []byte, masterKey []byte) ([]byte, error) {
+	plain, _, err := decryptDocument(byt, masterKey)
+	return plain, err
+}
+
+//decryptDocument Decrypt a document, along with the dotted key path of every decrypted value
+func decryptDocument(byt []byte, masterKey []byte) ([]byte, []string, error) {
+	doc, dataKey, err := openDocument(byt, masterKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dataKey == nil {
+		return byt, nil, nil
+	}
+
+	fields := make([]string, 0)
+	plain, err := transformValues(doc, nil, func(path []string, value interface{}) (interface{}, error) {
+		plain, err := decryptValue(dataKey, path, value)
+		if err != nil || plain == value {
+			return plain, err
+		}
+
+		//decrypted strings are secrets, keep them out of the logs
+		if s, ok := plain.(string); ok {
+			log.MaskSecret(s)
+		}
+		fields = append(fields, strings.Join(path, `.`))
+		return plain, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := yaml.Marshal(plain)
+	return out, fields, err
+}
+
+//EncryptDocument Encrypt the values of a document whose key path matches, values already
+//encrypted are kept. The data key of an encrypted document is reused, a new one is generated
+//otherwise. Json documents stay json
+func EncryptDocument(byt []byte, masterKey []byte, match func(path []string) bool) ([]byte, error) {
+	doc, dataKey, err := openDocument(byt, masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataKey == nil {
+		if dataKey, err = randomBytes(32); err != nil {
+			return nil, err
+		}
+	}
+
+	encrypted, err := transformValues(doc, nil, func(path []string, value interface{}) (interface{}, error) {
+		if s, ok := value.(string); (ok && encryptedValue.MatchString(s)) || !match(path) {
+			return value, nil
+		}
+		return encryptValue(dataKey, path, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sealDocument(byt, encrypted.(yaml.MapSlice), dataKey, masterKey)
+}
+
+//RotateDocument Re-encrypt the encrypted values of a document with a new data key, wrapped by
+//newMasterKey. Pass the same key twice to only replace the data key
+func RotateDocument(byt []byte, masterKey []byte, newMasterKey []byte) ([]byte, error) {
+	doc, dataKey, err := openDocument(byt, masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataKey == nil {
+		return nil, fmt.Errorf(`config: document is not encrypted`)
+	}
+
+	newDataKey, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := transformValues(doc, nil, func(path []string, value interface{}) (interface{}, error) {
+		if s, ok := value.(string); !ok || !encryptedValue.MatchString(s) {
+			return value, nil
+		}
+
+		plain, err := decryptValue(dataKey, path, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return encryptValue(newDataKey, path, plain)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sealDocument(byt, rotated.(yaml.MapSlice), newDataKey, newMasterKey)
+}
+
+//openDocument Parse a document and unwrap its data key, which is nil for plain documents
+func openDocument(byt []byte, masterKey []byte) (yaml.MapSlice, []byte, error) {
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(byt, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	doc, metadata, ok := splitMetadata(doc)
+	if !ok {
+		return doc, nil, nil
+	}
+
+	if metadata.KeyID != keyID(masterKey) {
+		return nil, nil, fmt.Errorf(`config: document is encrypted with key %s, not %s`, metadata.KeyID, keyID(masterKey))
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(metadata.EncryptedKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`config: invalid encrypted data key : %w`, err)
+	}
+
+	gcm, err := newGCM(masterKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, nil, fmt.Errorf(`config: invalid encrypted data key`)
+	}
+
+	dataKey, err := gcm.Open(nil, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`config: cannot decrypt data key : %w`, err)
+	}
+
+	return doc, dataKey, nil
+}
+
+//sealDocument Add the metadata of the data key to a document and encode it in the format of original
+func sealDocument(original []byte, doc yaml.MapSlice, dataKey []byte, masterKey []byte) ([]byte, error) {
+	gcm, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomBytes(gcm.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := yaml.MapSlice{
+		{Key: `encrypted_key`, Value: base64.StdEncoding.EncodeToString(append(nonce, gcm.Seal(nil, nonce, dataKey, nil)...))},
+		{Key: `key_id`, Value: keyID(masterKey)},
+		{Key: `lastmodified`, Value: time.Now().UTC().Format(time.RFC3339)},
+		{Key: `version`, Value: encryptionVersion},
+	}
+	doc = append(doc, yaml.MapItem{Key: encryptionMetadataKey, Value: metadata})
+
+	trimmed := bytes.TrimSpace(original)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		buf := &bytes.Buffer{}
+		if err := writeJSON(buf, doc); err != nil {
+			return nil, err
+		}
+
+		out := &bytes.Buffer{}
+		if err := json.Indent(out, buf.Bytes(), ``, `  `); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+
+		return out.Bytes(), nil
+	}
+
+	return yaml.Marshal(doc)
+}
+
+func splitMetadata(doc yaml.MapSlice) (yaml.MapSlice, encryptionMetadata, bool) {
+	metadata := encryptionMetadata{}
+	for i, item := range doc {
+		if item.Key != encryptionMetadataKey {
+			continue
+		}
+
+		byt, err := yaml.Marshal(item.Value)
+		if err != nil || yaml.Unmarshal(byt, &metadata) != nil || metadata.EncryptedKey == `` {
+			return doc, metadata, false
+		}
+
+		return append(append(yaml.MapSlice{}, doc[:i]...), doc[i+1:]...), metadata, true
+	}
+
+	return doc, metadata, false
+}
+
+//transformValues Replace every scalar of a document with the result of fn, called with its key path
+func transformValues(value interface{}, path []string, fn func(path []string, value interface{}) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		out := make(yaml.MapSlice, 0, len(v))
+		for _, item := range v {
+			next, err := transformValues(item.Value, append(append([]string{}, path...), fmt.Sprint(item.Key)), fn)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, yaml.MapItem{Key: item.Key, Value: next})
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			next, err := transformValues(item, append(append([]string{}, path...), strconv.Itoa(i)), fn)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, next)
+		}
+		return out, nil
+	case nil:
+		return nil, nil
+	default:
+		return fn(path, v)
+	}
+}
+
+func encryptValue(dataKey []byte, path []string, value interface{}) (interface{}, error) {
+	typ := `str`
+	switch value.(type) {
+	case int, int64, uint64:
+		typ = `int`
+	case float64:
+		typ = `float`
+	case bool:
+		typ = `bool`
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, err := randomBytes(gcm.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(fmt.Sprint(value)), []byte(strings.Join(path, `.`)))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return fmt.Sprintf(`ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]`,
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		typ,
+	), nil
+}
+
+func decryptValue(dataKey []byte, path []string, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+
+	parts := encryptedValue.FindStringSubmatch(s)
+	if parts == nil {
+		return value, nil
+	}
+
+	name := strings.Join(path, `.`)
+
+	raw := make([][]byte, 3)
+	for i := range raw {
+		decoded, err := base64.StdEncoding.DecodeString(parts[i+1])
+		if err != nil {
+			return nil, fmt.Errorf(`config: invalid encrypted value of %s : %w`, name, err)
+		}
+		raw[i] = decoded
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw[1]) != gcm.NonceSize() {
+		return nil, fmt.Errorf(`config: invalid encrypted value of %s`, name)
+	}
+
+	plain, err := gcm.Open(nil, raw[1], append(raw[0], raw[2]...), []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf(`config: cannot decrypt value of %s : %w`, name, err)
+	}
+
+	switch parts[4] {
+	case `int`:
+		return strconv.ParseInt(string(plain), 10, 64)
+	case `float`:
+		return strconv.ParseFloat(string(plain), 64)
+	case `bool`:
+		return strconv.ParseBool(string(plain))
+	}
+
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+//keyID Short fingerprint of a master key, to tell keys apart without revealing them
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func randomBytes(n int) ([]byte, error) {
+	byt := make([]byte, n)
+	if _, err := rand.Read(byt); err != nil {
+		return nil, err
+	}
+
+	return byt, nil
+}
+
+//writeJSON Encode a yaml document as json, keeping the order of keys
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(fmt.Sprint(item.Key))
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		byt, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(byt)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gopkg.in/yaml.v2"
+)
+
+type cryptTestConfig struct {
+	Host    string `yaml:"host" json:"host"`
+	APIKey  string `yaml:"api_key" json:"api_key"`
+	Retries int    `yaml:"retries" json:"retries"`
+}
+
+func testMasterKey(t *testing.T) []byte {
+	t.Helper()
+
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParseKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func matchKeys(keys ...string) func(path []string) bool {
+	return func(path []string) bool {
+		for _, key := range keys {
+			if strings.Join(path, `.`) == key {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestEncryptDocument(t *testing.T) {
+	key := testMasterKey(t)
+
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{name: `yaml`, document: "host: db\napi_key: k3y\nretries: 3\n"},
+		{name: `json`, document: `{"host":"db","api_key":"k3y","retries":3}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted, err := EncryptDocument([]byte(test.document), key, matchKeys(`api_key`, `retries`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte(`k3y`)) {
+				t.Fatalf(`expected api_key to be encrypted, got %s`, encrypted)
+			}
+			if !bytes.Contains(encrypted, []byte(`db`)) {
+				t.Errorf(`expected host to stay in clear, got %s`, encrypted)
+			}
+			if test.name == `json` && encrypted[0] != '{' {
+				t.Errorf(`expected a json document to stay json, got %s`, encrypted)
+			}
+
+			plain, err := DecryptDocument(encrypted, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := cryptTestConfig{}
+			if err := yaml.UnmarshalStrict(plain, &c); err != nil {
+				t.Fatal(err)
+			}
+			if c != (cryptTestConfig{Host: `db`, APIKey: `k3y`, Retries: 3}) {
+				t.Errorf(`expected the original values, got %+v`, c)
+			}
+		})
+	}
+}
+
+func TestDecryptDocumentErrors(t *testing.T) {
+	key := testMasterKey(t)
+
+	encrypted, err := EncryptDocument([]byte("api_key: k3y\n"), key, matchKeys(`api_key`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptDocument(encrypted, testMasterKey(t)); err == nil {
+		t.Error(`expected an error with another master key`)
+	}
+
+	//values are bound to their key path, moving one to another key fails
+	moved := bytes.Replace(encrypted, []byte(`api_key:`), []byte(`other_key:`), 1)
+	if _, err := DecryptDocument(moved, key); err == nil {
+		t.Error(`expected an error for a value moved to another key`)
+	}
+}
+
+func TestRotateDocument(t *testing.T) {
+	key, newKey := testMasterKey(t), testMasterKey(t)
+
+	encrypted, err := EncryptDocument([]byte("api_key: k3y\n"), key, matchKeys(`api_key`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := RotateDocument(encrypted, key, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptDocument(rotated, key); err == nil {
+		t.Error(`expected the old master key to be rejected`)
+	}
+
+	plain, err := DecryptDocument(rotated, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(plain, []byte(`k3y`)) {
+		t.Errorf(`expected the value to be kept, got %s`, plain)
+	}
+}
+
+func TestDecryptConfigRedacted(t *testing.T) {
+	key := testMasterKey(t)
+	t.Setenv(`CONFIG_KEY`, base64.StdEncoding.EncodeToString(key))
+
+	encrypted, err := EncryptDocument([]byte("host: db\napi_key: k3y\n"), key, matchKeys(`api_key`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := fstest.MapFS{`config/payments.yaml`: {Data: encrypted}}
+
+	c := cryptTestConfig{}
+	if err := NewYmlFSLoader(files).Load(`config/payments`, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKey != `k3y` {
+		t.Fatalf(`expected api_key to be decrypted, got %q`, c.APIKey)
+	}
+
+	//api_key is not a secret by its name, it is because it was encrypted
+	redactedConfig := Redact(`config/payments`, &c).(cryptTestConfig)
+	if redactedConfig.APIKey != redacted || redactedConfig.Host != `db` {
+		t.Errorf(`expected only api_key to be redacted, got %+v`, redactedConfig)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//DirLoader Fill configurations from a directory tree holding one file per key, as kubernetes
+//mounts ConfigMaps and Secrets. Directories and files are named after the config path and yaml
+//keys of each field. ex: write.password of config/database => <root>/database/write/password
+//
+//Trailing newlines of the files are trimmed, slices take comma separated values and slices of
+//structs are indexed directories (servers/0/host). Fields without a matching file are left untouched
+type DirLoader struct {
+	Root string
+}
+
+func NewDirLoader(root string) Loader {
+	return &DirLoader{
+		Root: root,
+	}
+}
+
+func (l *DirLoader) Load(path string, i interface{}) error {
+	v, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(l.Root, configName(path))
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf(`config: cannot read directory of %s : %w`, path, err)
+	}
+
+	return l.fill(v, dir)
+}
+
+func (l *DirLoader) fill(v reflect.Value, dir string) error {
+	return walkFields(v, nil, func(path []string, f reflect.StructField, v reflect.Value) error {
+		file := filepath.Join(append([]string{dir}, path...)...)
+
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct {
+			return l.fillStructs(v, file)
+		}
+
+		byt, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf(`config: cannot read %s : %w`, file, err)
+		}
+
+		if err := setFromString(v, strings.TrimRight(string(byt), "\r\n")); err != nil {
+			return fmt.Errorf(`config: invalid value in %s : %s`, file, err)
+		}
+
+		return nil
+	})
+}
+
+//fillStructs Fill a slice of structs from indexed directories, stopping at the first missing index
+func (l *DirLoader) fillStructs(v reflect.Value, dir string) error {
+	for i := 0; ; i++ {
+		item := filepath.Join(dir, strconv.Itoa(i))
+		if info, err := os.Stat(item); err != nil || !info.IsDir() {
+			return nil
+		}
+
+		if i >= v.Len() {
+			v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+		}
+
+		if err := l.fill(v.Index(i), item); err != nil {
+			return err
+		}
+	}
+}
+
+//Watch Notify when any file under the directory of a path is added, removed or modified
+func (l *DirLoader) Watch(path string, changed func()) (func(), error) {
+	dir := filepath.Join(l.Root, configName(path))
+
+	state, err := dirState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := FileWatchInterval
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current, err := dirState(dir)
+				if err != nil || current == state {
+					continue
+				}
+
+				state = current
+				changed()
+			}
+		}
+	}()
+
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}, nil
+}
+
+//dirState Fingerprint of the files of a tree (names, sizes and modification times).
+//Files are stat'ed through symlinks, so swaps of the kubernetes ..data link are noticed
+func dirState(dir string) (string, error) {
+	state := &strings.Builder{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(file); err == nil {
+				info = target
+			}
+		}
+
+		fmt.Fprintf(state, "%s %d %d\n", file, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+
+	return state.String(), err
+}
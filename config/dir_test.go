@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type dirTestServer struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type dirTestConfig struct {
+	Write struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password"`
+	} `yaml:"write"`
+	Hosts   []string        `yaml:"hosts"`
+	Servers []dirTestServer `yaml:"servers"`
+	Timeout time.Duration   `yaml:"timeout"`
+}
+
+func TestDirLoad(t *testing.T) {
+	root := t.TempDir()
+	for file, content := range map[string]string{
+		`database/write/host`:       `db`,
+		`database/write/password`:   "s3cret\n",
+		`database/hosts`:            `a, b`,
+		`database/servers/0/host`:   `s0`,
+		`database/servers/1/host`:   `s1`,
+		`database/servers/1/port`:   `3307`,
+		`database/servers/3/host`:   `skipped after the missing index 2`,
+		`database/timeout`:          "2s\r\n",
+		`database/unknown/variable`: `ignored`,
+	} {
+		writeTestFile(t, filepath.Join(root, file), content)
+	}
+
+	c := dirTestConfig{}
+	c.Write.Host = `kept unless set`
+	if err := NewDirLoader(root).Load(`config/database`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := dirTestConfig{}
+	expected.Write.Host = `db`
+	expected.Write.Password = `s3cret`
+	expected.Hosts = []string{`a`, `b`}
+	expected.Servers = []dirTestServer{{Host: `s0`}, {Host: `s1`, Port: 3307}}
+	expected.Timeout = 2 * time.Second
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf(`expected %+v, got %+v`, expected, c)
+	}
+}
+
+func TestDirLoadErrors(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, `redis`, `timeout`), `soon`)
+
+	tests := []struct {
+		path     string
+		notFound bool
+	}{
+		{path: `config/database`, notFound: true},
+		{path: `config/redis`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			err := NewDirLoader(root).Load(test.path, &dirTestConfig{})
+			if err == nil {
+				t.Fatal(`expected an error`)
+			}
+			if isNotFound(err) != test.notFound {
+				t.Errorf(`expected not found to be %v, got %v`, test.notFound, err)
+			}
+		})
+	}
+}
+
+func TestDirWatch(t *testing.T) {
+	setFileWatchInterval(t)
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, `database`, `write`, `host`), `db`)
+
+	changes := make(chan struct{}, 10)
+	stop, err := NewDirLoader(root).(*DirLoader).Watch(`config/database`, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(root, `database`, `write`, `password`), `s3cret`)
+	waitChanged(t, changes, `expected an added file to be a change`)
+
+	if err := os.Remove(filepath.Join(root, `database`, `write`, `password`)); err != nil {
+		t.Fatal(err)
+	}
+	waitChanged(t, changes, `expected a removed file to be a change`)
+
+	//stopping twice is harmless
+	stop()
+	stop()
+
+	writeTestFile(t, filepath.Join(root, `database`, `write`, `password`), `s3cret`)
+	select {
+	case <-changes:
+		t.Error(`expected no change once the watch is stopped`)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/danakum/go-util/log"
+	"gopkg.in/yaml.v2"
+)
+
+const redacted = `*****`
+
+//secretNames Field names always treated as secrets, on top of fields tagged secret:"true"
+var secretNames = map[string]bool{
+	`password`: true,
+	`passwd`:   true,
+	`secret`:   true,
+	`token`:    true,
+}
+
+//Dump Write the effective configuration in yaml or json, keyed by config path. The registrations
+//in configs are run against the current DefaultConfigurator and every other registered type is
+//loaded on its own, nothing connects to a database or broker. Secret fields are redacted.
+//A configuration which fails to load or validate is written as {config, errors} and the
+//failures are returned once everything is written
+func Dump(w io.Writer, format string, configs *Configurations) error {
+	loaded, failed := Effective(configs)
+
+	out := make(map[string]interface{}, len(loaded))
+	for path, i := range loaded {
+		out[path] = Redact(path, i)
+
+		if err, ok := failed[path]; ok {
+			out[path] = dumpFailure{Config: out[path], Errors: errorMessages(err)}
+		}
+	}
+
+	var byt []byte
+	var err error
+	switch format {
+	case `json`:
+		byt, err = json.MarshalIndent(out, ``, `  `)
+		byt = append(byt, '\n')
+	case `yaml`, ``:
+		byt, err = yaml.Marshal(out)
+	default:
+		return fmt.Errorf(`config: unknown dump format %s`, format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(byt); err != nil {
+		return err
+	}
+
+	errs := make(Errors, 0)
+	for _, path := range sortedKeys(failed) {
+		errs = errs.Append(failed[path])
+	}
+
+	return errs.Err()
+}
+
+//DumpCommand Entry point of a configdump command, run with the registrations of the service
+//so its own configuration types are dumped as well
+//
+//	func main() {
+//		config.DumpCommand(&configurations)
+//	}
+func DumpCommand(configs *Configurations) {
+	format := flag.String(`format`, `yaml`, `output format, yaml or json`)
+	flag.Parse()
+
+	//keep the output parsable
+	log.Config.Level = `ERROR`
+
+	if err := Dump(os.Stdout, *format, configs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+//dumpFailure Dumped configuration which failed to load or validate
+type dumpFailure struct {
+	Config interface{} `yaml:"config" json:"config"`
+	Errors []string    `yaml:"errors" json:"errors"`
+}
+
+//Effective Load every configuration the way the application would, keyed by path. Registered
+//types without a source are skipped. Configurations which fail to load or validate are kept
+//as far as they were loaded, with their error in failed
+func Effective(configs *Configurations) (loaded map[string]interface{}, failed map[string]error) {
+	loaded = make(map[string]interface{})
+	failed = make(map[string]error)
+
+	if configs != nil {
+		configurator, errorConfigurator := DefaultConfigurator, DefaultErrorConfigurator
+		recording := &recordingErrorConfigurator{ErrorConfigurator: errorConfigurator, loaded: loaded, failed: failed}
+		DefaultConfigurator = &recordingConfigurator{Configurator: configurator, recording: recording}
+		DefaultErrorConfigurator = recording
+
+		LoadConfiguration(configs)
+
+		DefaultConfigurator, DefaultErrorConfigurator = configurator, errorConfigurator
+	}
+
+	for _, path := range RegisteredPaths() {
+		if _, ok := loaded[path]; ok {
+			continue
+		}
+
+		typ, _ := RegisteredType(path)
+		i := reflect.New(typ).Interface()
+		if err := DefaultErrorConfigurator.Load(path, i); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			failed[path] = err
+		}
+
+		loaded[path] = i
+	}
+
+	return loaded, failed
+}
+
+func errorMessages(err error) []string {
+	errs, ok := err.(Errors)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	return messages
+}
+
+func sortedKeys(m map[string]error) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+//Redact Copy of a configuration with the values of secret fields replaced
+func Redact(path string, i interface{}) interface{} {
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return i
+	}
+
+	c := deepCopy(v)
+	_ = walkFields(c, nil, func(fieldPath []string, f reflect.StructField, v reflect.Value) error {
+		if !isSecretField(path, fieldPath, f) || v.IsZero() {
+			return nil
+		}
+
+		if v.Kind() == reflect.String {
+			v.SetString(redacted)
+			return nil
+		}
+
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	})
+
+	return c.Interface()
+}
+
+func isSecretField(path string, fieldPath []string, f reflect.StructField) bool {
+	if secret, ok := f.Tag.Lookup(`secret`); ok {
+		return secret == `true`
+	}
+
+	return secretNames[strings.ToLower(fieldPath[len(fieldPath)-1])] || IsSecret(path, strings.Join(fieldPath, `.`))
+}
+
+//recordingConfigurator Runs registrations without stopping the application on errors, they are recorded instead
+type recordingConfigurator struct {
+	Configurator
+	recording *recordingErrorConfigurator
+}
+
+func (c *recordingConfigurator) Load(path string, i interface{}, validator func(config interface{})) {
+	_ = c.recording.Load(path, i, func(config interface{}) error {
+		validator(config)
+		return nil
+	})
+}
+
+//recordingErrorConfigurator Records every loaded configuration and its error, and reports
+//success to the registration so it carries on with the next one
+type recordingErrorConfigurator struct {
+	ErrorConfigurator
+	loaded map[string]interface{}
+	failed map[string]error
+}
+
+func (c *recordingErrorConfigurator) Load(path string, i interface{}, validators ...Validator) error {
+	if err := c.ErrorConfigurator.Load(path, i, validators...); err != nil {
+		c.failed[path] = err
+	}
+	c.loaded[path] = i
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+type redactTestConfig struct {
+	Write struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password"`
+	} `yaml:"write"`
+	APIKey    string  `yaml:"api_key" secret:"true"`
+	Token     *string `yaml:"token"`
+	SecretTTL int     `yaml:"secret_ttl"`
+	Public    string  `yaml:"secret" secret:"false"`
+	Resolved  string  `yaml:"resolved"`
+	Empty     string  `yaml:"passwd"`
+}
+
+func TestRedact(t *testing.T) {
+	t.Setenv(`TEST_RESOLVED_SECRET`, `resolved-value`)
+
+	token := `t0ken`
+	c := redactTestConfig{APIKey: `k3y`, Token: &token, SecretTTL: 60, Public: `shown`, Resolved: `${env:TEST_RESOLVED_SECRET}`}
+	c.Write.Host = `db`
+	c.Write.Password = `pw`
+	if err := ResolveSecrets(`config/redact`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Redact(`config/redact`, &c).(redactTestConfig)
+
+	tests := []struct {
+		field    string
+		got      interface{}
+		expected interface{}
+	}{
+		{field: `write.host`, got: r.Write.Host, expected: `db`},
+		{field: `write.password by name`, got: r.Write.Password, expected: redacted},
+		{field: `api_key by tag`, got: r.APIKey, expected: redacted},
+		{field: `token by name`, got: r.Token == nil, expected: true},
+		{field: `secret_ttl`, got: r.SecretTTL, expected: 60},
+		{field: `secret tagged false`, got: r.Public, expected: `shown`},
+		{field: `resolved from a secret`, got: r.Resolved, expected: redacted},
+		{field: `empty secret`, got: r.Empty, expected: ``},
+	}
+
+	for _, test := range tests {
+		if test.got != test.expected {
+			t.Errorf(`expected %v for %s, got %v`, test.expected, test.field, test.got)
+		}
+	}
+
+	//the configuration itself is left untouched
+	if c.Write.Password != `pw` || *c.Token != `t0ken` {
+		t.Errorf(`expected the original to keep its values, got %+v`, c)
+	}
+}
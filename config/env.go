@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var envInvalidChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+//EnvLoader Fill configurations from environment variables named after the config path
+//and yaml keys of each field. ex: write.host of config/database => DATABASE_WRITE_HOST
+//
+//Slices take comma separated values, slices of structs are indexed (SERVERS_0_HOST).
+//Fields without a matching variable are left untouched
+type EnvLoader struct {
+	Prefix string
+}
+
+func NewEnvLoader(prefix string) Loader {
+	return &EnvLoader{
+		Prefix: prefix,
+	}
+}
+
+func (l *EnvLoader) Load(path string, i interface{}) error {
+	v, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	return l.fill(v, []string{l.Prefix, configName(path)})
+}
+
+func (l *EnvLoader) fill(v reflect.Value, prefix []string) error {
+	return walkFields(v, prefix, func(path []string, f reflect.StructField, v reflect.Value) error {
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct {
+			return l.fillStructs(v, path)
+		}
+
+		name := envName(path)
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+
+		if err := setFromString(v, raw); err != nil {
+			return fmt.Errorf(`config: invalid value for %s : %s`, name, err)
+		}
+
+		return nil
+	})
+}
+
+//fillStructs Fill a slice of structs from indexed variables, stopping at the first missing index
+func (l *EnvLoader) fillStructs(v reflect.Value, path []string) error {
+	for i := 0; hasEnvPrefix(envName(append(path, strconv.Itoa(i))) + `_`); i++ {
+		if i >= v.Len() {
+			v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+		}
+
+		if err := l.fill(v.Index(i), append(append([]string{}, path...), strconv.Itoa(i))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func envName(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, p := range path {
+		p = strings.Trim(envInvalidChars.ReplaceAllString(strings.ToUpper(p), `_`), `_`)
+		if p != `` {
+			parts = append(parts, p)
+		}
+	}
+
+	return strings.Join(parts, `_`)
+}
+
+func hasEnvPrefix(prefix string) bool {
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
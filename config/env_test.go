@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type envTestServer struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type envTestConfig struct {
+	Write struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	} `yaml:"write"`
+	MaxOpen  int             `yaml:"max_open_connections"`
+	Debug    bool            `yaml:"debug"`
+	Timeout  time.Duration   `yaml:"timeout"`
+	Hosts    []string        `yaml:"hosts"`
+	Servers  []envTestServer `yaml:"servers"`
+	Password *string         `yaml:"password"`
+	Ignored  string          `yaml:"-"`
+	Named    string          `json:"json_name"`
+}
+
+func TestEnvLoad(t *testing.T) {
+	for name, value := range map[string]string{
+		`TEST_DATABASE_WRITE_HOST`:           `db`,
+		`TEST_DATABASE_MAX_OPEN_CONNECTIONS`: `10`,
+		`TEST_DATABASE_DEBUG`:                `true`,
+		`TEST_DATABASE_TIMEOUT`:              `2s`,
+		`TEST_DATABASE_HOSTS`:                `a, b,`,
+		`TEST_DATABASE_SERVERS_0_HOST`:       `s0`,
+		`TEST_DATABASE_SERVERS_1_PORT`:       `3307`,
+		`TEST_DATABASE_SERVERS_3_HOST`:       `skipped after the missing index 2`,
+		`TEST_DATABASE_PASSWORD`:             `pw`,
+		`TEST_DATABASE_IGNORED`:              `ignored`,
+		`TEST_DATABASE_JSON_NAME`:            `named`,
+	} {
+		t.Setenv(name, value)
+	}
+
+	c := envTestConfig{}
+	c.Write.Port = 3306
+	if err := NewEnvLoader(`TEST`).Load(`config/database`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	password := `pw`
+	expected := envTestConfig{
+		MaxOpen:  10,
+		Debug:    true,
+		Timeout:  2 * time.Second,
+		Hosts:    []string{`a`, `b`},
+		Servers:  []envTestServer{{Host: `s0`}, {Port: 3307}},
+		Password: &password,
+		Named:    `named`,
+	}
+	expected.Write.Host = `db`
+	expected.Write.Port = 3306
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf(`expected %+v, got %+v`, expected, c)
+	}
+}
+
+func TestEnvLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: `TEST_DATABASE_MAX_OPEN_CONNECTIONS`, value: `many`},
+		{name: `TEST_DATABASE_DEBUG`, value: `maybe`},
+		{name: `TEST_DATABASE_TIMEOUT`, value: `2`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(test.name, test.value)
+
+			if err := NewEnvLoader(`TEST`).Load(`config/database`, &envTestConfig{}); err == nil {
+				t.Errorf(`expected an error for %s=%s`, test.name, test.value)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		path     []string
+		expected string
+	}{
+		{path: []string{``, `database`, `write`, `host`}, expected: `DATABASE_WRITE_HOST`},
+		{path: []string{`app`, `database`, `max_open`}, expected: `APP_DATABASE_MAX_OPEN`},
+		{path: []string{`my-app`, `feature.flags`, `rollout`}, expected: `MY_APP_FEATURE_FLAGS_ROLLOUT`},
+	}
+
+	for _, test := range tests {
+		if got := envName(test.path); got != test.expected {
+			t.Errorf(`expected %s for %v, got %s`, test.expected, test.path, got)
+		}
+	}
+}
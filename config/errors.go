@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Errors Every problem found while loading or validating a configuration
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf(`%d configuration errors : %s`, len(e), strings.Join(messages, `; `))
+}
+
+//Append Add an error to the list, nil errors are ignored and nested lists are flattened
+func (e Errors) Append(err error) Errors {
+	if err == nil {
+		return e
+	}
+
+	if errs, ok := err.(Errors); ok {
+		for _, err := range errs {
+			e = e.Append(err)
+		}
+		return e
+	}
+
+	return append(e, err)
+}
+
+//Err The list as an error, nil when there is nothing in it
+func (e Errors) Err() error {
+	if len(e) < 1 {
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+//EtcdLoader Read configurations from etcd v3 keys through its json gateway (/v3/kv/range).
+//Keys hold json or yaml documents, as zookeeper nodes do
+type EtcdLoader struct {
+	client *kvClient
+	mu     *sync.Mutex
+	token  string
+}
+
+//NewEtcdLoader Create an etcd loader. When a username is set it is exchanged for an auth
+//token on first use, otherwise options.Token is sent as is
+func NewEtcdLoader(options KVOptions) (*EtcdLoader, error) {
+	client, err := newKVClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtcdLoader{
+		client: client,
+		mu:     &sync.Mutex{},
+		token:  options.Token,
+	}, nil
+}
+
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+type etcdKV struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	ModRevision string `json:"mod_revision"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader `json:"header"`
+	Kvs    []etcdKV   `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader `json:"header"`
+		Created         bool       `json:"created"`
+		Canceled        bool       `json:"canceled"`
+		CompactRevision string     `json:"compact_revision"`
+		Events          []struct {
+			Type string `json:"type"`
+			Kv   etcdKV `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (l *EtcdLoader) Load(path string, i interface{}) error {
+	value, _, err := l.get(path)
+	if err != nil {
+		return err
+	}
+
+	if value, err = DecryptConfig(path, value); err != nil {
+		return fmt.Errorf(`etcd cannot decrypt key %s : %w`, l.client.key(path), err)
+	}
+
+	if err := decodeDocument(value, i, l.client.options.Strict); err != nil {
+		return fmt.Errorf(`etcd cannot decode key %s : %w`, l.client.key(path), err)
+	}
+
+	return nil
+}
+
+//get Value of the key of a path and the revision of the store it was read at
+func (l *EtcdLoader) get(path string) ([]byte, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.client.options.Timeout)
+	defer cancel()
+
+	res := etcdRangeResponse{}
+	err := l.post(ctx, `/v3/kv/range`, map[string]string{
+		`key`: base64.StdEncoding.EncodeToString([]byte(l.client.key(path))),
+	}, &res)
+	if err != nil {
+		return nil, 0, fmt.Errorf(`etcd cannot read key %s : %w`, l.client.key(path), err)
+	}
+
+	revision, _ := strconv.ParseInt(res.Header.Revision, 10, 64)
+	if len(res.Kvs) < 1 {
+		return nil, revision, fmt.Errorf(`etcd cannot read key %s : %w`, l.client.key(path), ErrKeyNotFound)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(res.Kvs[0].Value)
+	if err != nil {
+		return nil, revision, fmt.Errorf(`etcd cannot decode value of key %s : %w`, l.client.key(path), err)
+	}
+
+	return value, revision, nil
+}
+
+//Watch Notify on every change of the key behind a path. The watch resumes from the last seen
+//revision when the stream breaks, and a compacted history is reported as a change since
+//updates may have been missed
+func (l *EtcdLoader) Watch(path string, changed func()) (func(), error) {
+	_, revision, err := l.get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := l.client.key(path)
+	return watchLoop(`etcd key `+key, func(stop <-chan struct{}) error {
+		ctx, cancel := stopContext(stop)
+		defer cancel()
+
+		body, err := json.Marshal(map[string]interface{}{
+			`create_request`: map[string]interface{}{
+				`key`:            base64.StdEncoding.EncodeToString([]byte(key)),
+				`start_revision`: strconv.FormatInt(revision+1, 10),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		res, err := l.request(ctx, `/v3/watch`, body)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		decoder := json.NewDecoder(res.Body)
+		for {
+			event := etcdWatchResponse{}
+			if err := decoder.Decode(&event); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+
+			if event.Error != nil {
+				return fmt.Errorf(`%s`, event.Error.Message)
+			}
+
+			if current, err := strconv.ParseInt(event.Result.Header.Revision, 10, 64); err == nil && current > revision {
+				revision = current
+			}
+
+			if compacted, _ := strconv.ParseInt(event.Result.CompactRevision, 10, 64); compacted > 0 {
+				changed()
+				return nil
+			}
+
+			if event.Result.Canceled {
+				return fmt.Errorf(`watch canceled`)
+			}
+
+			if len(event.Result.Events) > 0 {
+				changed()
+			}
+		}
+	}), nil
+}
+
+//post Send a json request to the gateway and decode its response
+func (l *EtcdLoader) post(ctx context.Context, uri string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	res, err := l.request(ctx, uri, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return json.NewDecoder(res.Body).Decode(response)
+}
+
+//request Send a request with the auth token, authenticating first when a username is set.
+//Only successful responses are returned
+func (l *EtcdLoader) request(ctx context.Context, uri string, body []byte) (*http.Response, error) {
+	token, err := l.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := l.client.do(ctx, func(endpoint string) (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, endpoint+uri, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set(`Content-Type`, `application/json`)
+		if token != `` {
+			req.Header.Set(`Authorization`, token)
+		}
+
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+
+		if res.StatusCode == http.StatusUnauthorized && l.client.options.Username != `` {
+			l.mu.Lock()
+			l.token = ``
+			l.mu.Unlock()
+		}
+
+		return nil, fmt.Errorf(`etcd responded %s : %s`, res.Status, bytes.TrimSpace(msg))
+	}
+
+	return res, nil
+}
+
+//authenticate Token to send, exchanged for the username and password when there is none yet
+func (l *EtcdLoader) authenticate(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.token != `` || l.client.options.Username == `` {
+		return l.token, nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		`name`:     l.client.options.Username,
+		`password`: l.client.options.Password,
+	})
+	if err != nil {
+		return ``, err
+	}
+
+	res, err := l.client.do(ctx, func(endpoint string) (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, endpoint+`/v3/auth/authenticate`, bytes.NewReader(body))
+	})
+	if err != nil {
+		return ``, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ``, fmt.Errorf(`etcd authentication failed : %s`, res.Status)
+	}
+
+	auth := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&auth); err != nil {
+		return ``, err
+	}
+
+	l.token = auth.Token
+	return l.token, nil
+}
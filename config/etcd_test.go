@@ -0,0 +1,308 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+//fakeEtcd Stand-in of the etcd v3 json gateway keeping the history of every put
+type fakeEtcd struct {
+	mu        sync.Mutex
+	revision  int64
+	compacted int64
+	values    map[string]etcdKV
+	history   []etcdKV
+	notify    chan struct{}
+	//starts Start revision of every watch request
+	starts []int64
+	//breakStream End watch streams after each batch of events, as a broken connection would
+	breakStream bool
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{
+		revision: 1,
+		values:   make(map[string]etcdKV),
+		notify:   make(chan struct{}),
+	}
+}
+
+func (e *fakeEtcd) put(key string, value string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.revision++
+	kv := etcdKV{
+		Key:         base64.StdEncoding.EncodeToString([]byte(key)),
+		Value:       base64.StdEncoding.EncodeToString([]byte(value)),
+		ModRevision: strconv.FormatInt(e.revision, 10),
+	}
+	e.values[key] = kv
+	e.history = append(e.history, kv)
+
+	close(e.notify)
+	e.notify = make(chan struct{})
+}
+
+func (e *fakeEtcd) compact() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.compacted = e.revision
+}
+
+func (e *fakeEtcd) watchStarts() []int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]int64{}, e.starts...)
+}
+
+func (e *fakeEtcd) header() etcdHeader {
+	return etcdHeader{Revision: strconv.FormatInt(e.revision, 10)}
+}
+
+func (e *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case `/v3/kv/range`:
+		e.serveRange(w, r)
+	case `/v3/watch`:
+		e.serveWatch(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (e *fakeEtcd) serveRange(w http.ResponseWriter, r *http.Request) {
+	request := struct {
+		Key string `json:"key"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, _ := base64.StdEncoding.DecodeString(request.Key)
+
+	e.mu.Lock()
+	res := etcdRangeResponse{Header: e.header()}
+	if kv, ok := e.values[string(key)]; ok {
+		res.Kvs = []etcdKV{kv}
+	}
+	e.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (e *fakeEtcd) serveWatch(w http.ResponseWriter, r *http.Request) {
+	request := struct {
+		CreateRequest struct {
+			Key           string `json:"key"`
+			StartRevision string `json:"start_revision"`
+		} `json:"create_request"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	start, _ := strconv.ParseInt(request.CreateRequest.StartRevision, 10, 64)
+
+	encoder := json.NewEncoder(w)
+	send := func(res etcdWatchResponse) {
+		_ = encoder.Encode(res)
+		w.(http.Flusher).Flush()
+	}
+
+	e.mu.Lock()
+	e.starts = append(e.starts, start)
+	res := etcdWatchResponse{}
+	res.Result.Header = e.header()
+	if start <= e.compacted {
+		res.Result.Canceled = true
+		res.Result.CompactRevision = strconv.FormatInt(e.compacted, 10)
+		e.mu.Unlock()
+		send(res)
+		return
+	}
+	e.mu.Unlock()
+
+	res.Result.Created = true
+	send(res)
+
+	next := start
+	for {
+		e.mu.Lock()
+		res := etcdWatchResponse{}
+		res.Result.Header = e.header()
+		for _, kv := range e.history {
+			if kv.Key != request.CreateRequest.Key {
+				continue
+			}
+
+			if revision, _ := strconv.ParseInt(kv.ModRevision, 10, 64); revision >= next {
+				res.Result.Events = append(res.Result.Events, struct {
+					Type string `json:"type"`
+					Kv   etcdKV `json:"kv"`
+				}{Type: `PUT`, Kv: kv})
+				next = revision + 1
+			}
+		}
+		notify, breakStream := e.notify, e.breakStream
+		e.mu.Unlock()
+
+		if len(res.Result.Events) > 0 {
+			send(res)
+			if breakStream {
+				return
+			}
+		}
+
+		select {
+		case <-notify:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func newTestEtcdLoader(t *testing.T, etcd *fakeEtcd) *EtcdLoader {
+	server := httptest.NewServer(etcd)
+	t.Cleanup(server.Close)
+
+	//the first endpoint is down, requests fail over to the stand-in
+	loader, err := NewEtcdLoader(KVOptions{
+		Endpoints: []string{`http://127.0.0.1:1`, server.URL},
+		Prefix:    `/services/orders/`,
+		Timeout:   time.Second,
+		Strict:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loader
+}
+
+type kvTestConfig struct {
+	Host string `yaml:"host" json:"host"`
+	Port int    `yaml:"port" json:"port"`
+}
+
+func TestEtcdLoad(t *testing.T) {
+	etcd := newFakeEtcd()
+	etcd.put(`/services/orders/config/database`, `{"host":"db","port":5432}`)
+	etcd.put(`/services/orders/config/redis`, "host: cache\nport: 6379\n")
+	loader := newTestEtcdLoader(t, etcd)
+
+	for path, expected := range map[string]kvTestConfig{
+		`config/database`: {Host: `db`, Port: 5432},
+		`config/redis`:    {Host: `cache`, Port: 6379},
+	} {
+		c := kvTestConfig{}
+		if err := loader.Load(path, &c); err != nil {
+			t.Fatal(err)
+		}
+		if c != expected {
+			t.Errorf(`expected %+v for %s, got %+v`, expected, path, c)
+		}
+	}
+
+	if err := loader.Load(`config/missing`, &kvTestConfig{}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf(`expected key not found, got %v`, err)
+	}
+}
+
+func TestEtcdWatchResumesFromLastRevision(t *testing.T) {
+	etcd := newFakeEtcd()
+	etcd.breakStream = true
+	etcd.put(`/services/orders/config/database`, `{"host":"db"}`)
+	loader := newTestEtcdLoader(t, etcd)
+
+	changes := make(chan struct{}, 10)
+	stop, err := loader.Watch(`config/database`, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	//revision 2 is loaded, the watch starts after it
+	etcd.put(`/services/orders/config/database`, `{"host":"db3"}`)
+	waitChanged(t, changes, `expected a change of revision 3`)
+
+	//the stream is broken, the change is replayed once the watch resumes
+	etcd.put(`/services/orders/config/database`, `{"host":"db4"}`)
+	waitChanged(t, changes, `expected a change of revision 4 after resuming`)
+
+	starts := etcd.watchStarts()
+	if len(starts) < 2 || starts[0] != 3 || starts[1] != 4 {
+		t.Errorf(`expected watches to start at revisions 3 then 4, got %v`, starts)
+	}
+}
+
+func TestEtcdWatchCompacted(t *testing.T) {
+	etcd := newFakeEtcd()
+	etcd.breakStream = true
+	etcd.put(`/services/orders/config/database`, `{"host":"db"}`)
+	loader := newTestEtcdLoader(t, etcd)
+
+	changes := make(chan struct{}, 10)
+	stop, err := loader.Watch(`config/database`, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	etcd.put(`/services/orders/config/database`, `{"host":"db3"}`)
+	waitChanged(t, changes, `expected a change of revision 3`)
+
+	//changes made while disconnected are compacted away, they are reported as one change
+	etcd.put(`/services/orders/config/other`, `{}`)
+	etcd.put(`/services/orders/config/other`, `{}`)
+	etcd.compact()
+	waitChanged(t, changes, `expected a compacted history to be reported as a change`)
+
+	//the watch resumes after the current revision instead of the compacted one
+	etcd.put(`/services/orders/config/database`, `{"host":"db6"}`)
+	waitChanged(t, changes, `expected a change of revision 6 after the compaction`)
+
+	starts := etcd.watchStarts()
+	if len(starts) < 3 || fmt.Sprint(starts[:3]) != `[3 4 6]` {
+		t.Errorf(`expected watches to start at revisions 3, 4 then 6, got %v`, starts)
+	}
+}
+
+func TestEtcdWatchStop(t *testing.T) {
+	etcd := newFakeEtcd()
+	etcd.put(`/services/orders/config/database`, `{"host":"db"}`)
+	loader := newTestEtcdLoader(t, etcd)
+
+	changes := make(chan struct{}, 10)
+	stop, err := loader.Watch(`config/database`, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//stopping twice is harmless
+	stop()
+	stop()
+
+	etcd.put(`/services/orders/config/database`, `{"host":"db3"}`)
+	select {
+	case <-changes:
+		t.Error(`expected no change once the watch is stopped`)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
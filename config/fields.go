@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//fieldName Resolve the configuration key of a struct field from its yaml tag,
+//falling back to the json tag and then to the lower cased field name
+func fieldName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != `` {
+		return ``, false
+	}
+
+	for _, tag := range []string{`yaml`, `json`} {
+		value, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		name := strings.Split(value, `,`)[0]
+		if name == `-` {
+			return ``, false
+		}
+
+		if name != `` {
+			return name, true
+		}
+	}
+
+	return strings.ToLower(f.Name), true
+}
+
+//configName Name of the configuration behind a loader path (config/database => database)
+func configName(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+//structValue Dereference the pointer passed to a loader and make sure it points to a struct
+func structValue(i interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, fmt.Errorf(`config: expected a non nil pointer to a struct, got %T`, i)
+	}
+
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf(`config: expected a pointer to a struct, got %T`, i)
+	}
+
+	return v, nil
+}
+
+//isLeaf Whether a value is set as a whole rather than descended into
+func isLeaf(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() != reflect.Struct
+}
+
+//walkFields Call fn for every leaf field of a struct with the yaml key path leading to it
+func walkFields(v reflect.Value, path []string, fn func(path []string, f reflect.StructField, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+
+		fieldPath := append(append([]string{}, path...), name)
+		fv := v.Field(i)
+
+		if isLeaf(f.Type) {
+			if err := fn(fieldPath, f, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		if err := walkFields(fv, fieldPath, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//setFromString Parse a raw string value into a leaf field. Slices take comma separated values
+func setFromString(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		values := make([]string, 0)
+		for _, item := range strings.Split(raw, `,`) {
+			if item = strings.TrimSpace(item); item != `` {
+				values = append(values, item)
+			}
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, item := range values {
+			if err := setFromString(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf(`unsupported type %s`, v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+//FileWatchInterval How often watched configuration files are checked for changes
+var FileWatchInterval = 5 * time.Second
+
+//watchFile Poll a file for changes. The file is stat'ed through symlinks, so atomic swaps
+//of the link target (as done for kubernetes ConfigMap volumes) are reported as changes
+func watchFile(file string, changed func()) (func(), error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := FileWatchInterval
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current, err := os.Stat(file)
+				if err != nil {
+					//the file can be missing for a moment while it is replaced
+					continue
+				}
+
+				if os.SameFile(info, current) && current.ModTime().Equal(info.ModTime()) && current.Size() == info.Size() {
+					continue
+				}
+
+				info = current
+				changed()
+			}
+		}
+	}()
+
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}, nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setFileWatchInterval(t *testing.T) {
+	previous := FileWatchInterval
+	FileWatchInterval = 10 * time.Millisecond
+	t.Cleanup(func() {
+		FileWatchInterval = previous
+	})
+}
+
+func writeTestFile(t *testing.T, file string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitChanged(t *testing.T, changes <-chan struct{}, message string) {
+	t.Helper()
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal(message)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	setFileWatchInterval(t)
+
+	file := filepath.Join(t.TempDir(), `database.yaml`)
+	writeTestFile(t, file, `host: db`)
+
+	changes := make(chan struct{}, 10)
+	stop, err := watchFile(file, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	writeTestFile(t, file, `host: db2`)
+	waitChanged(t, changes, `expected a change of the file`)
+
+	select {
+	case <-changes:
+		t.Error(`expected no change while the file stays the same`)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchFileSymlinkSwap(t *testing.T) {
+	setFileWatchInterval(t)
+
+	//kubernetes ConfigMap volumes link the file to ..data, which is swapped atomically
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, `v1`, `database.yaml`), `host: db`)
+	writeTestFile(t, filepath.Join(dir, `v2`, `database.yaml`), `host: db`)
+	if err := os.Symlink(`v1`, filepath.Join(dir, `..data`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(`..data`, `database.yaml`), filepath.Join(dir, `database.yaml`)); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan struct{}, 10)
+	stop, err := watchFile(filepath.Join(dir, `database.yaml`), func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if err := os.Symlink(`v2`, filepath.Join(dir, `..data_tmp`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, `..data_tmp`), filepath.Join(dir, `..data`)); err != nil {
+		t.Fatal(err)
+	}
+	waitChanged(t, changes, `expected the swap of the link target to be a change`)
+}
+
+func TestWatchFileStop(t *testing.T) {
+	setFileWatchInterval(t)
+
+	file := filepath.Join(t.TempDir(), `database.yaml`)
+	writeTestFile(t, file, `host: db`)
+
+	changes := make(chan struct{}, 10)
+	stop, err := watchFile(file, func() {
+		changes <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//stopping twice is harmless
+	stop()
+	stop()
+
+	writeTestFile(t, file, `host: db2`)
+	select {
+	case <-changes:
+		t.Error(`expected no change once the watch is stopped`)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if _, err := watchFile(filepath.Join(t.TempDir(), `missing.yaml`), func() {}); !os.IsNotExist(err) {
+		t.Errorf(`expected a missing file to be reported, got %v`, err)
+	}
+}
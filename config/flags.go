@@ -0,0 +1,178 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/danakum/go-util/log"
+)
+
+//DefaultFlagLoader Binds registered configurations to the command line, it is the last layer
+//of the default configurators so flags take precedence over every other source
+var DefaultFlagLoader = NewFlagLoader(flag.CommandLine)
+
+//FlagLoader Fill configurations from command line flags named after the config path and
+//yaml keys of each field. ex: write.host of config/database => --database.write.host
+//
+//Flags are defined by Bind and only flags explicitly set on the command line are applied, the
+//application parses the command line itself once every configuration is bound:
+//
+//	config.BindRegisteredFlags()
+//	flag.Parse()
+//	config.LoadConfiguration(&configurations)
+//
+//Slices take comma separated values, maps and slices of structs cannot be set from flags
+type FlagLoader struct {
+	flags    *flag.FlagSet
+	mu       *sync.RWMutex
+	prefixes map[string]string
+}
+
+func NewFlagLoader(flags *flag.FlagSet) *FlagLoader {
+	return &FlagLoader{
+		flags:    flags,
+		mu:       &sync.RWMutex{},
+		prefixes: make(map[string]string),
+	}
+}
+
+//fieldFlag flag.Value of a config field, holding the raw string until the field is loaded
+type fieldFlag struct {
+	value  string
+	isBool bool
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil {
+		return ``
+	}
+	return f.value
+}
+
+func (f *fieldFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+//Bind Define a flag for every leaf field of the configuration of path, prefixed with prefix
+//(--prefix.write.host), or named after the field alone when prefix is empty (--port). The default
+//shown in --help is taken from the default tag, or from the value of the field in i
+func (l *FlagLoader) Bind(path string, prefix string, i interface{}) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf(`config: expected a struct to bind flags of %s, got %T`, path, i)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.prefixes[path]; ok {
+		return nil
+	}
+	l.prefixes[path] = prefix
+
+	return walkFields(v, nil, func(fieldPath []string, f reflect.StructField, v reflect.Value) error {
+		if v.Kind() == reflect.Map || (v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct) {
+			return nil
+		}
+
+		name := flagName(prefix, fieldPath)
+		if l.flags.Lookup(name) != nil {
+			return nil
+		}
+
+		def, ok := f.Tag.Lookup(`default`)
+		if !ok && !v.IsZero() {
+			def = fmt.Sprint(v.Interface())
+		}
+
+		//The backquoted type is shown as the name of the value in --help
+		usage := fmt.Sprintf("%s of %s as `%s`", strings.Join(fieldPath, `.`), path, v.Type())
+		l.flags.Var(&fieldFlag{value: def, isBool: v.Kind() == reflect.Bool}, name, usage)
+
+		return nil
+	})
+}
+
+//Load Apply the flags set on the command line to a configuration bound with Bind. Nothing is
+//applied before the command line is parsed
+func (l *FlagLoader) Load(path string, i interface{}) error {
+	v, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	l.mu.RLock()
+	prefix, ok := l.prefixes[path]
+	l.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if !l.flags.Parsed() {
+		return nil
+	}
+
+	set := make(map[string]string)
+	l.flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	return walkFields(v, nil, func(fieldPath []string, _ reflect.StructField, v reflect.Value) error {
+		name := flagName(prefix, fieldPath)
+		raw, ok := set[name]
+		if !ok {
+			return nil
+		}
+
+		if err := setFromString(v, raw); err != nil {
+			return fmt.Errorf(`config: invalid value for --%s : %s`, name, err)
+		}
+
+		return nil
+	})
+}
+
+//BindFlags Bind a configuration to the command line flags of DefaultFlagLoader
+func BindFlags(path string, prefix string, i interface{}) {
+	if err := DefaultFlagLoader.Bind(path, prefix, i); err != nil {
+		log.Error(`cannot bind flags of `+path, err)
+	}
+}
+
+//BindRegisteredFlags Bind every type registered with RegisterType to the command line flags of
+//DefaultFlagLoader, prefixed with the name of their path (--database.write.host) except for
+//config/app (--port). config/logger is read by the log package without the configurators, so it has no flags
+func BindRegisteredFlags() {
+	for _, path := range RegisteredPaths() {
+		if path == `config/logger` {
+			continue
+		}
+
+		typ, _ := RegisteredType(path)
+
+		prefix := configName(path)
+		if path == `config/app` {
+			prefix = ``
+		}
+		BindFlags(path, prefix, reflect.New(typ).Interface())
+	}
+}
+
+func flagName(prefix string, path []string) string {
+	if prefix == `` {
+		return strings.Join(path, `.`)
+	}
+
+	return prefix + `.` + strings.Join(path, `.`)
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type flagTestConfig struct {
+	Write struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port" default:"3306"`
+	} `yaml:"write"`
+	Hosts   []string          `yaml:"hosts"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Debug   bool              `yaml:"debug"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+func newTestFlagLoader(t *testing.T, prefix string) (*FlagLoader, *flag.FlagSet) {
+	t.Helper()
+
+	flags := flag.NewFlagSet(`test`, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+
+	loader := NewFlagLoader(flags)
+	if err := loader.Bind(`config/database`, prefix, &flagTestConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return loader, flags
+}
+
+func TestFlagLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		args     []string
+		expected func(c *flagTestConfig)
+	}{
+		{
+			name:   `prefixed`,
+			prefix: `database`,
+			args:   []string{`--database.write.host`, `db`, `--database.hosts`, `a, b`, `--database.timeout=2s`, `--database.debug`},
+			expected: func(c *flagTestConfig) {
+				c.Write.Host = `db`
+				c.Hosts = []string{`a`, `b`}
+				c.Timeout = 2 * time.Second
+				c.Debug = true
+			},
+		},
+		{
+			name: `unprefixed`,
+			args: []string{`--write.port`, `3307`},
+			expected: func(c *flagTestConfig) {
+				c.Write.Port = 3307
+			},
+		},
+		{
+			name:     `nothing set`,
+			prefix:   `database`,
+			expected: func(c *flagTestConfig) {},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loader, flags := newTestFlagLoader(t, test.prefix)
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			//flags not set on the command line leave the loaded value untouched
+			c := flagTestConfig{}
+			c.Write.Port = 5432
+			expected := c
+			test.expected(&expected)
+
+			if err := loader.Load(`config/database`, &c); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c, expected) {
+				t.Errorf(`expected %+v, got %+v`, expected, c)
+			}
+		})
+	}
+}
+
+func TestFlagBind(t *testing.T) {
+	loader, flags := newTestFlagLoader(t, `database`)
+
+	if flags.Lookup(`database.labels`) != nil {
+		t.Error(`expected no flag for a map field`)
+	}
+
+	f := flags.Lookup(`database.write.port`)
+	if f == nil || f.DefValue != `3306` {
+		t.Fatalf(`expected the default tag to be the default of the flag, got %+v`, f)
+	}
+
+	//a path is bound once
+	if err := loader.Bind(`config/database`, `db`, &flagTestConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if flags.Lookup(`db.write.host`) != nil {
+		t.Error(`expected a bound path not to be bound again`)
+	}
+}
+
+func TestFlagLoadBeforeParse(t *testing.T) {
+	loader, flags := newTestFlagLoader(t, `database`)
+	_ = flags.Set(`database.write.host`, `db`)
+
+	c := flagTestConfig{}
+	if err := loader.Load(`config/database`, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Write.Host != `` {
+		t.Errorf(`expected nothing to be applied before parsing, got %q`, c.Write.Host)
+	}
+}
+
+func TestFlagLoadInvalid(t *testing.T) {
+	loader, flags := newTestFlagLoader(t, `database`)
+	if err := flags.Parse([]string{`--database.write.port`, `high`}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loader.Load(`config/database`, &flagTestConfig{}); err == nil {
+		t.Error(`expected an error for a value which is not a number`)
+	}
+}
+
+func TestBindRegisteredFlags(t *testing.T) {
+	BindRegisteredFlags()
+
+	if flag.Lookup(`port`) == nil {
+		t.Error(`expected config/app to be bound without a prefix`)
+	}
+	if flag.Lookup(`logger.level`) != nil {
+		t.Error(`expected config/logger not to be bound`)
+	}
+}
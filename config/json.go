@@ -1,21 +1,31 @@
 package config
 
 import (
+	"fmt"
 	"github.com/danakum/go-util/log"
 	"gopkg.in/yaml.v2"
-	"io/ioutil"
+	"io/fs"
 )
 
-type JsonFileLoader struct{}
+type JsonFileLoader struct {
+	fs fs.FS
+}
 
 func NewJsonFileLoader() Loader {
 	return new(JsonFileLoader)
 }
 
+//NewJsonFSLoader Read json files from a file system instead of the config root, ex: an embed.FS
+func NewJsonFSLoader(fsys fs.FS) Loader {
+	return &JsonFileLoader{
+		fs: fsys,
+	}
+}
+
 func (l *JsonFileLoader) Load(path string, i interface{}) error {
 
 	file := path + `.json`
-	byt, err := ioutil.ReadFile(file)
+	byt, err := readConfigFile(l.fs, path, `.json`)
 	if err != nil {
 		log.Error(`cannot load file `, err)
 		return err
@@ -25,3 +35,12 @@ func (l *JsonFileLoader) Load(path string, i interface{}) error {
 
 	return yaml.Unmarshal(byt, i)
 }
+
+//Watch Notify when the json file behind a path or its profile file changes
+func (l *JsonFileLoader) Watch(path string, changed func()) (func(), error) {
+	if l.fs != nil {
+		return nil, fmt.Errorf(`%w : %s is read from a file system`, ErrWatchNotSupported, path)
+	}
+
+	return watchConfigFile(path, `.json`, changed)
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danakum/go-util/log"
+)
+
+//ErrKeyNotFound Returned by key/value loaders when the key of a path does not exist
+var ErrKeyNotFound = errors.New(`config: key not found`)
+
+//KVOptions Connection settings of the etcd and consul loaders. Keys are Prefix + path
+//(ex: /services/orders/ + config/database), the same mapping as ZK_CONFIG_PATH for zookeeper
+type KVOptions struct {
+	Endpoints  []string      `yaml:"endpoints" json:"endpoints"` //ex: https://127.0.0.1:2379, tried in order
+	Prefix     string        `yaml:"prefix" json:"prefix"`
+	Token      string        `yaml:"token" json:"token" secret:"true"`
+	Username   string        `yaml:"username" json:"username"` //etcd only, exchanged for a token
+	Password   string        `yaml:"password" json:"password" secret:"true"`
+	Datacenter string        `yaml:"datacenter" json:"datacenter"` //consul only
+	Timeout    time.Duration `yaml:"timeout" json:"timeout" default:"5s"`
+	Strict     bool          `yaml:"strict" json:"strict"`
+	TLS        TLSOptions    `yaml:"tls" json:"tls"`
+}
+
+type TLSOptions struct {
+	CAFile             string `yaml:"ca_file" json:"ca_file"`
+	CertFile           string `yaml:"cert_file" json:"cert_file"`
+	KeyFile            string `yaml:"key_file" json:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+}
+
+//KVOptionsFromEnv Read options from variables named after name and the yaml keys of
+//KVOptions. ex: for etcd ETCD_ENDPOINTS, ETCD_PREFIX, ETCD_TOKEN, ETCD_TLS_CA_FILE
+func KVOptionsFromEnv(name string) (KVOptions, error) {
+	options := KVOptions{}
+	if err := NewDefaultLoader().Load(name, &options); err != nil {
+		return options, err
+	}
+
+	if err := NewEnvLoader(``).Load(name, &options); err != nil {
+		return options, err
+	}
+
+	if len(options.Endpoints) < 1 {
+		return options, fmt.Errorf(`config: no endpoints configured for %s`, name)
+	}
+
+	return options, nil
+}
+
+//kvClient Http client shared by the key/value loaders, with endpoint failover
+type kvClient struct {
+	options KVOptions
+	http    *http.Client
+}
+
+func newKVClient(options KVOptions) (*kvClient, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = 5 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig, err := options.TLS.config()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &kvClient{
+		options: options,
+		//no client timeout, watches are long lived requests. Loads are bound by options.Timeout
+		http: &http.Client{Transport: transport},
+	}, nil
+}
+
+func (o TLSOptions) config() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != `` {
+		ca, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf(`config: cannot read ca file : %w`, err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf(`config: no certificate found in %s`, o.CAFile)
+		}
+	}
+
+	if o.CertFile != `` || o.KeyFile != `` {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf(`config: cannot load client certificate : %w`, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+//key Key of a config path
+func (c *kvClient) key(path string) string {
+	return c.options.Prefix + path
+}
+
+//do Send a request to each endpoint in turn until one answers. build creates the request for an
+//endpoint, responses are returned whatever their status
+func (c *kvClient) do(ctx context.Context, build func(endpoint string) (*http.Request, error)) (*http.Response, error) {
+	var lastErr error
+	for _, endpoint := range c.options.Endpoints {
+		req, err := build(strings.TrimRight(endpoint, `/`))
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.http.Do(req.WithContext(ctx))
+		if err == nil {
+			return res, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, fmt.Errorf(`config: no endpoints configured`)
+	}
+
+	return nil, lastErr
+}
+
+//watchLoop Run watch until stopped, waiting a second between failed attempts
+func watchLoop(name string, watch func(stop <-chan struct{}) error) func() {
+	stop := make(chan struct{})
+	go func() {
+		for {
+			err := watch(stop)
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			if err != nil {
+				log.Error(`cannot watch `+name+`, retrying`, err)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}
+}
+
+//stopContext Context cancelled when stop is closed
+func stopContext(stop <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
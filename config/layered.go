@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+//Layer A named configuration source of a LayeredLoader
+type Layer struct {
+	Name     string
+	Loader   Loader
+	Optional bool //Skip the layer when its file or node does not exist
+}
+
+//LayeredLoader Merge several loaders field by field. Each layer is loaded on top of the result
+//of the previous ones, so later layers take precedence over earlier ones
+type LayeredLoader struct {
+	layers  []Layer
+	mu      *sync.RWMutex
+	sources map[string]map[string]string
+}
+
+func NewLayeredLoader(layers ...Layer) *LayeredLoader {
+	return &LayeredLoader{
+		layers:  layers,
+		mu:      &sync.RWMutex{},
+		sources: make(map[string]map[string]string),
+	}
+}
+
+func (l *LayeredLoader) Load(path string, i interface{}) error {
+	target, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	merged := deepCopy(target)
+	sources := make(map[string]string)
+
+	for _, layer := range l.layers {
+		next := reflect.New(target.Type())
+		next.Elem().Set(deepCopy(merged))
+
+		if err := layer.Loader.Load(path, next.Interface()); err != nil {
+			if layer.Optional && isNotFound(err) {
+				continue
+			}
+			return fmt.Errorf(`config: cannot load layer %s of %s : %w`, layer.Name, path, err)
+		}
+
+		err = walkFields(next.Elem(), nil, func(fieldPath []string, _ reflect.StructField, v reflect.Value) error {
+			previous := fieldByPath(merged, fieldPath)
+			if !previous.IsValid() || !reflect.DeepEqual(v.Interface(), previous.Interface()) {
+				sources[strings.Join(fieldPath, `.`)] = layer.Name
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		merged = next.Elem()
+	}
+
+	target.Set(merged)
+
+	l.mu.Lock()
+	l.sources[path] = sources
+	l.mu.Unlock()
+
+	return nil
+}
+
+//Sources Name of the layer each field of a loaded configuration came from, keyed by the
+//dotted yaml path of the field (ex: write.host). Fields no layer has set are omitted
+func (l *LayeredLoader) Sources(path string) map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	sources := make(map[string]string, len(l.sources[path]))
+	for field, source := range l.sources[path] {
+		sources[field] = source
+	}
+
+	return sources
+}
+
+//DefaultLoader Fill zero valued fields from their default tag (ex: `default:"3306"`)
+type DefaultLoader struct{}
+
+func NewDefaultLoader() Loader {
+	return new(DefaultLoader)
+}
+
+func (DefaultLoader) Load(path string, i interface{}) error {
+	v, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	return walkFields(v, nil, func(fieldPath []string, f reflect.StructField, v reflect.Value) error {
+		def, ok := f.Tag.Lookup(`default`)
+		if !ok || !v.IsZero() {
+			return nil
+		}
+
+		if err := setFromString(v, def); err != nil {
+			return fmt.Errorf(`config: invalid default for %s.%s : %s`, path, strings.Join(fieldPath, `.`), err)
+		}
+
+		return nil
+	})
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, zk.ErrNoNode) || errors.Is(err, ErrKeyNotFound)
+}
+
+//fieldByPath Find a field of a struct by its yaml key path. The returned value is invalid
+//when a nil pointer stands in the way
+func fieldByPath(v reflect.Value, path []string) reflect.Value {
+	for _, name := range path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+
+		field := reflect.Value{}
+		for i := 0; i < v.NumField(); i++ {
+			if n, ok := fieldName(v.Type().Field(i)); ok && n == name {
+				field = v.Field(i)
+				break
+			}
+		}
+
+		if !field.IsValid() {
+			return field
+		}
+		v = field
+	}
+
+	return v
+}
+
+//deepCopy Copy a value without sharing pointers, slices or maps with the original
+func deepCopy(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+
+	switch v.Kind() {
+	case reflect.Struct:
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return c
+		}
+		c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return c
+		}
+		c.Set(reflect.New(v.Type().Elem()))
+		c.Elem().Set(deepCopy(v.Elem()))
+	case reflect.Map:
+		if v.IsNil() {
+			return c
+		}
+		c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, deepCopy(v.MapIndex(key)))
+		}
+	default:
+		c.Set(v)
+	}
+
+	return c
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+//layeredTestFiles Loader reading yaml documents by path, standing in for config/<name>.yaml
+type layeredTestFiles map[string]string
+
+func (f layeredTestFiles) Load(path string, i interface{}) error {
+	document, ok := f[path]
+	if !ok {
+		return os.ErrNotExist
+	}
+
+	return yaml.Unmarshal([]byte(document), i)
+}
+
+type layeredTestTLS struct {
+	Cert string `yaml:"cert"`
+}
+
+type layeredTestConfig struct {
+	Host    string          `yaml:"host" default:"localhost"`
+	Port    int             `yaml:"port" default:"3306"`
+	Timeout string          `yaml:"timeout"`
+	TLS     *layeredTestTLS `yaml:"tls"`
+}
+
+func TestLayeredLoad(t *testing.T) {
+	t.Setenv(`TEST_DATABASE_PORT`, `3307`)
+
+	files := layeredTestFiles{
+		`config/database`: "host: db\ntls:\n  cert: db.pem\n",
+	}
+
+	loader := NewLayeredLoader(
+		Layer{Name: `defaults`, Loader: NewDefaultLoader()},
+		Layer{Name: `file`, Loader: files},
+		Layer{Name: `env`, Loader: NewEnvLoader(`TEST`)},
+	)
+
+	//tls is nil until the file layer sets it
+	c := layeredTestConfig{}
+	if err := loader.Load(`config/database`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := layeredTestConfig{Host: `db`, Port: 3307, TLS: &layeredTestTLS{Cert: `db.pem`}}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf(`expected %+v, got %+v`, expected, c)
+	}
+
+	for field, source := range map[string]string{
+		`host`:     `file`,
+		`port`:     `env`,
+		`tls.cert`: `file`,
+	} {
+		if got := loader.Sources(`config/database`)[field]; got != source {
+			t.Errorf(`expected %s to come from %s, got %q`, field, source, got)
+		}
+	}
+
+	if _, ok := loader.Sources(`config/database`)[`timeout`]; ok {
+		t.Error(`expected no source for a field no layer has set`)
+	}
+}
+
+func TestLayeredOptionalLayer(t *testing.T) {
+	files := layeredTestFiles{}
+
+	tests := []struct {
+		name     string
+		optional bool
+		err      bool
+	}{
+		{name: `optional`, optional: true},
+		{name: `required`, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loader := NewLayeredLoader(
+				Layer{Name: `defaults`, Loader: NewDefaultLoader()},
+				Layer{Name: `file`, Loader: files, Optional: test.optional},
+			)
+
+			c := layeredTestConfig{}
+			err := loader.Load(`config/database`, &c)
+			if (err != nil) != test.err {
+				t.Fatalf(`expected error %v, got %v`, test.err, err)
+			}
+			if err != nil && !isNotFound(err) {
+				t.Errorf(`expected a not found error, got %v`, err)
+			}
+			if err == nil && c.Port != 3306 {
+				t.Errorf(`expected the defaults to be kept, got %+v`, c)
+			}
+		})
+	}
+}
+
+func TestFieldByPath(t *testing.T) {
+	c := layeredTestConfig{Host: `db`}
+	v := reflect.ValueOf(c)
+
+	if got := fieldByPath(v, []string{`host`}); !got.IsValid() || got.String() != `db` {
+		t.Errorf(`expected host to be found, got %v`, got)
+	}
+
+	//the pointer to the tls section is nil, there is no field to compare with
+	if got := fieldByPath(v, []string{`tls`, `cert`}); got.IsValid() {
+		t.Errorf(`expected an invalid value behind a nil pointer, got %v`, got)
+	}
+
+	if got := fieldByPath(v, []string{`missing`}); got.IsValid() {
+		t.Errorf(`expected an invalid value for an unknown field, got %v`, got)
+	}
+}
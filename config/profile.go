@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/danakum/go-util/config/configfs"
+	"gopkg.in/yaml.v2"
+)
+
+var profileFlag = flag.String(`profile`, ``, `configuration profile, config/<name>.<profile>.yaml is merged over config/<name>.yaml`)
+
+//Profile Active configuration profile (dev, staging, prod...) taken from the -profile flag
+//once the command line is parsed, or from APP_PROFILE
+func Profile() string {
+	if flag.Parsed() && *profileFlag != `` {
+		return *profileFlag
+	}
+
+	return os.Getenv(`APP_PROFILE`)
+}
+
+//profileFile File overriding path+ext under the active profile (config/database.prod.yaml)
+func profileFile(path string, ext string) string {
+	if Profile() == `` {
+		return ``
+	}
+
+	return path + `.` + Profile() + ext
+}
+
+//readConfigFile Read path+ext deep merged with the file of the active profile when there is one.
+//Json is a subset of yaml so both are merged as yaml. Files are read from fsys, or through
+//configfs when it is nil, and encrypted files are decrypted before being merged
+func readConfigFile(fsys fs.FS, path string, ext string) ([]byte, error) {
+	byt, err := readFile(fsys, path+ext)
+	if err != nil {
+		return nil, err
+	}
+
+	if byt, err = DecryptConfig(path, byt); err != nil {
+		return nil, fmt.Errorf(`cannot decrypt %s : %w`, path+ext, err)
+	}
+
+	overlayFile := profileFile(path, ext)
+	if overlayFile == `` {
+		return byt, nil
+	}
+
+	overlay, err := readFile(fsys, overlayFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return byt, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if overlay, err = DecryptConfig(path, overlay); err != nil {
+		return nil, fmt.Errorf(`cannot decrypt %s : %w`, overlayFile, err)
+	}
+
+	return MergeYaml(byt, overlay)
+}
+
+//MergeYaml Deep merge the overlay document over the base one
+func MergeYaml(base []byte, overlay []byte) ([]byte, error) {
+	baseMap := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(base, &baseMap); err != nil {
+		return nil, err
+	}
+
+	overlayMap := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(overlay, &overlayMap); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(deepMerge(baseMap, overlayMap))
+}
+
+func readFile(fsys fs.FS, file string) ([]byte, error) {
+	if fsys == nil {
+		return configfs.ReadFile(file)
+	}
+
+	return fs.ReadFile(fsys, file)
+}
+
+//watchConfigFile Watch path+ext and the file of the active profile
+func watchConfigFile(path string, ext string, changed func()) (func(), error) {
+	stop, err := watchFile(configfs.Path(path+ext), changed)
+	if err != nil {
+		return nil, err
+	}
+
+	overlayFile := profileFile(path, ext)
+	if overlayFile == `` {
+		return stop, nil
+	}
+
+	stopOverlay, err := watchFile(configfs.Path(overlayFile), changed)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stop, nil
+	}
+	if err != nil {
+		stop()
+		return nil, err
+	}
+
+	return func() {
+		stop()
+		stopOverlay()
+	}, nil
+}
+
+//deepMerge Merge override into base, nested maps are merged key by key and any other value is replaced
+func deepMerge(base map[interface{}]interface{}, override map[interface{}]interface{}) map[interface{}]interface{} {
+	for key, value := range override {
+		baseMap, baseIsMap := base[key].(map[interface{}]interface{})
+		overrideMap, overrideIsMap := value.(map[interface{}]interface{})
+
+		if baseIsMap && overrideIsMap {
+			base[key] = deepMerge(baseMap, overrideMap)
+			continue
+		}
+
+		base[key] = value
+	}
+
+	return base
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		overlay  string
+		expected string
+	}{
+		{
+			name:     `nested maps are merged`,
+			base:     "write:\n  host: db\n  port: 3306\n",
+			overlay:  "write:\n  host: prod-db\n",
+			expected: "write:\n  host: prod-db\n  port: 3306\n",
+		},
+		{
+			name:     `slices are replaced`,
+			base:     "hosts: [a, b]\n",
+			overlay:  "hosts: [c]\n",
+			expected: "hosts: [c]\n",
+		},
+		{
+			name:     `a map replaces a scalar`,
+			base:     "write: db\n",
+			overlay:  "write:\n  host: prod-db\n",
+			expected: "write:\n  host: prod-db\n",
+		},
+		{
+			name:     `new keys are added`,
+			base:     "host: db\n",
+			overlay:  "debug: true\n",
+			expected: "host: db\ndebug: true\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base, overlay, expected := yamlMap(t, test.base), yamlMap(t, test.overlay), yamlMap(t, test.expected)
+
+			if merged := deepMerge(base, overlay); !reflect.DeepEqual(merged, expected) {
+				t.Errorf(`expected %v, got %v`, expected, merged)
+			}
+		})
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, `database.yaml`), "write:\n  host: db\n  port: 3306\n")
+	writeTestFile(t, filepath.Join(dir, `database.prod.yaml`), "write:\n  host: prod-db\n")
+	writeTestFile(t, filepath.Join(dir, `redis.yaml`), "host: cache\n")
+
+	tests := []struct {
+		name     string
+		profile  string
+		path     string
+		expected string
+	}{
+		{name: `no profile`, path: `database`, expected: "write:\n  host: db\n  port: 3306\n"},
+		{name: `profile`, profile: `prod`, path: `database`, expected: "write:\n  host: prod-db\n  port: 3306\n"},
+		{name: `profile without overlay`, profile: `prod`, path: `redis`, expected: "host: cache\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(`APP_PROFILE`, test.profile)
+
+			byt, err := readConfigFile(nil, filepath.Join(dir, test.path), `.yaml`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := yamlMap(t, string(byt)); !reflect.DeepEqual(got, yamlMap(t, test.expected)) {
+				t.Errorf(`expected %s, got %s`, test.expected, byt)
+			}
+		})
+	}
+}
+
+func yamlMap(t *testing.T, document string) map[interface{}]interface{} {
+	t.Helper()
+
+	m := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal([]byte(document), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/danakum/go-util/log"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	registryMu = &sync.RWMutex{}
+	registry   = make(map[string]reflect.Type)
+	lenient    = make(map[string]bool)
+)
+
+func init() {
+	RegisterType(`config/app`, AppConfig{})
+	RegisterType(`config/logger`, log.LogConfig{})
+}
+
+//RegisterType Make the type of a configuration known to tooling (schemas, linting, dumps and
+//BindRegisteredFlags) under its path
+func RegisterType(path string, config interface{}) {
+	typ := reflect.TypeOf(config)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	registryMu.Lock()
+	registry[path] = typ
+	registryMu.Unlock()
+}
+
+//RegisteredType Type registered under a path
+func RegisteredType(path string) (reflect.Type, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	typ, ok := registry[path]
+	return typ, ok
+}
+
+//RegisteredPaths Paths of every registered configuration type, sorted
+func RegisteredPaths() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	paths := make([]string, 0, len(registry))
+	for path := range registry {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+//AllowUnknownFields Decode the yaml files of a path without rejecting keys its type does not have,
+//for files written before configurations were decoded strictly
+func AllowUnknownFields(path string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	lenient[path] = true
+}
+
+//decodeConfigFile Decode a yaml configuration file, strictly unless the path allows unknown fields
+func decodeConfigFile(path string, byt []byte, i interface{}) error {
+	registryMu.RLock()
+	allowed := lenient[path]
+	registryMu.RUnlock()
+
+	if allowed {
+		return yaml.Unmarshal(byt, i)
+	}
+
+	return yaml.UnmarshalStrict(byt, i)
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+)
+
+//Schema JSON Schema (draft-07) of a configuration type, built from its yaml, default and validate tags
+func Schema(path string, typ reflect.Type) map[string]interface{} {
+	schema := typeSchema(typ)
+	schema[`$schema`] = `http://json-schema.org/draft-07/schema#`
+	schema[`title`] = path
+
+	return schema
+}
+
+func typeSchema(typ reflect.Type) map[string]interface{} {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == durationType {
+		return map[string]interface{}{`type`: `string`}
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := make([]string, 0)
+
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			name, ok := fieldName(f)
+			if !ok {
+				continue
+			}
+
+			property := typeSchema(f.Type)
+			if def, ok := f.Tag.Lookup(`default`); ok {
+				property[`default`] = def
+			}
+
+			if rules, ok := f.Tag.Lookup(`validate`); ok {
+				for _, rule := range strings.Split(rules, `,`) {
+					if strings.TrimSpace(rule) == `required` {
+						required = append(required, name)
+						continue
+					}
+					applyRule(property, f.Type, strings.TrimSpace(rule))
+				}
+			}
+
+			properties[name] = property
+		}
+
+		schema := map[string]interface{}{
+			`type`:                 `object`,
+			`properties`:           properties,
+			`additionalProperties`: false,
+		}
+		if len(required) > 0 {
+			schema[`required`] = required
+		}
+
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{`type`: `array`, `items`: typeSchema(typ.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{`type`: `object`, `additionalProperties`: typeSchema(typ.Elem())}
+	case reflect.String:
+		return map[string]interface{}{`type`: `string`}
+	case reflect.Bool:
+		return map[string]interface{}{`type`: `boolean`}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{`type`: `integer`}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{`type`: `number`}
+	}
+
+	return map[string]interface{}{}
+}
+
+//applyRule Express a validate rule as schema keywords
+func applyRule(schema map[string]interface{}, typ reflect.Type, rule string) {
+	name, arg := rule, ``
+	if i := strings.Index(rule, `=`); i > -1 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case `min`, `max`:
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return
+		}
+
+		keyword := map[reflect.Kind]string{reflect.String: `Length`, reflect.Slice: `Items`, reflect.Map: `Properties`}[typ.Kind()]
+		if keyword == `` {
+			schema[map[string]string{`min`: `minimum`, `max`: `maximum`}[name]] = limit
+			return
+		}
+		schema[name+keyword] = limit
+	case `oneof`:
+		options := make([]interface{}, 0)
+		for _, option := range strings.Fields(arg) {
+			options = append(options, option)
+		}
+		schema[`enum`] = options
+	}
+}
+
+//Lint Decode a configuration file the way the loaders do and check it against the type
+//registered under path, reporting every problem found
+func Lint(path string, byt []byte) error {
+	typ, ok := RegisteredType(path)
+	if !ok {
+		return fmt.Errorf(`no configuration type registered for %s`, path)
+	}
+
+	i := reflect.New(typ).Interface()
+	if err := decodeConfigFile(path, byt, i); err != nil {
+		return fmt.Errorf(`%s : %s`, path, err)
+	}
+
+	if err := NewDefaultLoader().Load(path, i); err != nil {
+		return err
+	}
+
+	return Validate(path, i)
+}
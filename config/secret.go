@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/danakum/go-util/log"
+)
+
+//SecretResolver Resolve the reference part of a ${<scheme>:<reference>} config value
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+//SecretResolverFunc Plain functions as SecretResolvers
+type SecretResolverFunc func(ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	secretRef = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]*)\}`)
+
+	secretsMu       = &sync.RWMutex{}
+	secretResolvers = map[string]SecretResolver{
+		`env`:  SecretResolverFunc(envSecret),
+		`file`: SecretResolverFunc(fileSecret),
+	}
+	secretFields    = make(map[string]map[string]bool)
+	decryptedFields = make(map[string]map[string]bool)
+)
+
+//RegisterSecretResolver Resolve ${<scheme>:...} references through r (ex: a vault client)
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	secretResolvers[scheme] = r
+}
+
+//ResolveSecrets Replace the secret references in every string field of a configuration.
+//Resolved values are masked in the log output and their fields are reported by IsSecret
+func ResolveSecrets(path string, i interface{}) error {
+	v, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	errs := make(Errors, 0)
+	fields := make(map[string]bool)
+
+	_ = walkFields(v, nil, func(fieldPath []string, _ reflect.StructField, v reflect.Value) error {
+		name := strings.Join(fieldPath, `.`)
+
+		values := []reflect.Value{v}
+		if v.Kind() == reflect.Slice {
+			values = values[:0]
+			for i := 0; i < v.Len(); i++ {
+				values = append(values, v.Index(i))
+			}
+		}
+
+		for _, value := range values {
+			if value.Kind() != reflect.String || !secretRef.MatchString(value.String()) {
+				continue
+			}
+
+			resolved, err := resolveSecrets(value.String())
+			if err != nil {
+				errs = errs.Append(fmt.Errorf(`%s.%s : %s`, path, name, err))
+				continue
+			}
+
+			value.SetString(resolved)
+			fields[name] = true
+		}
+
+		return nil
+	})
+
+	secretsMu.Lock()
+	secretFields[path] = fields
+	secretsMu.Unlock()
+
+	return errs.Err()
+}
+
+//IsSecret Whether a field of a configuration (dotted yaml path, ex: write.password) was resolved from
+//a secret or decrypted
+func IsSecret(path string, field string) bool {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	return secretFields[path][field] || decryptedFields[path][field]
+}
+
+//markSecrets Report fields of a configuration decrypted by DecryptConfig as secrets
+func markSecrets(path string, fields []string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	if decryptedFields[path] == nil {
+		decryptedFields[path] = make(map[string]bool)
+	}
+	for _, field := range fields {
+		decryptedFields[path][field] = true
+	}
+}
+
+func resolveSecrets(value string) (string, error) {
+	var resolveErr error
+
+	resolved := secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+		match := secretRef.FindStringSubmatch(ref)
+
+		secretsMu.RLock()
+		resolver, ok := secretResolvers[match[1]]
+		secretsMu.RUnlock()
+
+		if !ok {
+			resolveErr = fmt.Errorf(`no secret resolver registered for %s`, match[1])
+			return ref
+		}
+
+		secret, err := resolver.Resolve(match[2])
+		if err != nil {
+			resolveErr = fmt.Errorf(`cannot resolve secret %s : %s`, ref, err)
+			return ref
+		}
+
+		log.MaskSecret(secret)
+		return secret
+	})
+
+	return resolved, resolveErr
+}
+
+func envSecret(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return ``, fmt.Errorf(`environment variable %s is not set`, name)
+	}
+
+	return value, nil
+}
+
+func fileSecret(file string) (string, error) {
+	byt, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ``, err
+	}
+
+	return strings.TrimRight(string(byt), "\r\n"), nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type secretTestConfig struct {
+	Write struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password"`
+	} `yaml:"write"`
+	Tokens []string `yaml:"tokens"`
+	Port   int      `yaml:"port"`
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv(`TEST_DB_PASS`, `pw`)
+
+	file := filepath.Join(t.TempDir(), `token`)
+	if err := ioutil.WriteFile(file, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterSecretResolver(`vault`, SecretResolverFunc(func(ref string) (string, error) {
+		if ref != `secret/db` {
+			return ``, errors.New(`no such secret`)
+		}
+		return `vault-token`, nil
+	}))
+
+	c := secretTestConfig{}
+	c.Write.Host = `db`
+	c.Write.Password = `${env:TEST_DB_PASS}`
+	c.Tokens = []string{`${file:` + file + `}`, `prefix-${vault:secret/db}`}
+
+	if err := ResolveSecrets(`config/database`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := secretTestConfig{}
+	expected.Write.Host = `db`
+	expected.Write.Password = `pw`
+	expected.Tokens = []string{`file-token`, `prefix-vault-token`}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf(`expected %+v, got %+v`, expected, c)
+	}
+
+	for field, secret := range map[string]bool{
+		`write.password`: true,
+		`tokens`:         true,
+		`write.host`:     false,
+	} {
+		if IsSecret(`config/database`, field) != secret {
+			t.Errorf(`expected IsSecret of %s to be %v`, field, secret)
+		}
+	}
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: `unknown scheme`, value: `${unknown:ref}`},
+		{name: `missing variable`, value: `${env:TEST_MISSING_VARIABLE}`},
+		{name: `missing file`, value: `${file:/does/not/exist}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := secretTestConfig{}
+			c.Write.Password = test.value
+
+			err := ResolveSecrets(`config/database`, &c)
+			if err == nil {
+				t.Fatal(`expected an error`)
+			}
+			if c.Write.Password != test.value {
+				t.Errorf(`expected an unresolved value to be kept, got %q`, c.Write.Password)
+			}
+		})
+	}
+}
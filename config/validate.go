@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//Validate Check a configuration against the validate tags of its fields and report every
+//failure with the full path of the field (ex: config/database.read.port : cannot be empty)
+//
+//Supported rules, comma separated:
+//	required     the value cannot be empty
+//	min=n, max=n bounds of numbers, or of the length of strings and slices
+//	oneof=a b c  the value should be one of the space separated options
+func Validate(path string, i interface{}) error {
+	v, err := structValue(i)
+	if err != nil {
+		return err
+	}
+
+	return validateStruct(path, v).Err()
+}
+
+func validateStruct(path string, v reflect.Value) Errors {
+	errs := make(Errors, 0)
+
+	_ = walkFields(v, nil, func(fieldPath []string, f reflect.StructField, v reflect.Value) error {
+		name := path + `.` + strings.Join(fieldPath, `.`)
+
+		if rules, ok := f.Tag.Lookup(`validate`); ok {
+			for _, rule := range strings.Split(rules, `,`) {
+				if err := checkRule(v, strings.TrimSpace(rule)); err != nil {
+					errs = errs.Append(fmt.Errorf(`%s : %s`, name, err))
+				}
+			}
+		}
+
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < v.Len(); i++ {
+				errs = errs.Append(validateStruct(fmt.Sprintf(`%s[%d]`, name, i), v.Index(i)).Err())
+			}
+		}
+
+		return nil
+	})
+
+	return errs
+}
+
+func checkRule(v reflect.Value, rule string) error {
+	name, arg := rule, ``
+	if i := strings.Index(rule, `=`); i > -1 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case ``:
+		return nil
+	case `required`:
+		if v.IsZero() || (v.Kind() == reflect.Slice && v.Len() < 1) {
+			return fmt.Errorf(`cannot be empty`)
+		}
+	case `min`, `max`:
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf(`invalid rule %s`, rule)
+		}
+
+		size, ok := sizeOf(v)
+		if !ok {
+			return fmt.Errorf(`rule %s is not supported for %s`, rule, v.Type())
+		}
+
+		if name == `min` && size < limit {
+			return fmt.Errorf(`should be at least %s`, arg)
+		}
+
+		if name == `max` && size > limit {
+			return fmt.Errorf(`should be at most %s`, arg)
+		}
+	case `oneof`:
+		value := fmt.Sprint(v.Interface())
+		options := strings.Fields(arg)
+		for _, option := range options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf(`should be one of %s, got %q`, strings.Join(options, `, `), value)
+	default:
+		return fmt.Errorf(`unknown rule %s`, rule)
+	}
+
+	return nil
+}
+
+//sizeOf Value of numbers, length of strings, slices and maps
+func sizeOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(v.Len()), true
+	}
+
+	return 0, false
+}
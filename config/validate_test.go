@@ -0,0 +1,116 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+type validateTestServer struct {
+	Host string `yaml:"host" validate:"required"`
+}
+
+type validateTestConfig struct {
+	Write struct {
+		Host string `yaml:"host" validate:"required"`
+		Port int    `yaml:"port" validate:"min=1,max=65535"`
+	} `yaml:"write"`
+	Name    string               `yaml:"name" validate:"min=2,max=4"`
+	Hosts   []string             `yaml:"hosts" validate:"required"`
+	Mode    string               `yaml:"mode" validate:"oneof=text json"`
+	Ratio   float64              `yaml:"ratio" validate:"max=1"`
+	Servers []validateTestServer `yaml:"servers"`
+}
+
+func validTestConfig() validateTestConfig {
+	c := validateTestConfig{
+		Name:    `app`,
+		Hosts:   []string{`a`},
+		Mode:    `json`,
+		Ratio:   0.5,
+		Servers: []validateTestServer{{Host: `s0`}},
+	}
+	c.Write.Host = `db`
+	c.Write.Port = 3306
+
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(c *validateTestConfig)
+		expected []string
+	}{
+		{name: `valid`, change: func(c *validateTestConfig) {}},
+		{
+			name:     `required`,
+			change:   func(c *validateTestConfig) { c.Write.Host = ``; c.Hosts = []string{} },
+			expected: []string{`config/database.write.host : cannot be empty`, `config/database.hosts : cannot be empty`},
+		},
+		{
+			name:     `bounds of numbers`,
+			change:   func(c *validateTestConfig) { c.Write.Port = 70000; c.Ratio = 1.5 },
+			expected: []string{`config/database.write.port : should be at most 65535`, `config/database.ratio : should be at most 1`},
+		},
+		{
+			name:     `bounds of lengths`,
+			change:   func(c *validateTestConfig) { c.Name = `a` },
+			expected: []string{`config/database.name : should be at least 2`},
+		},
+		{
+			name:     `oneof`,
+			change:   func(c *validateTestConfig) { c.Mode = `xml` },
+			expected: []string{`config/database.mode : should be one of text, json, got "xml"`},
+		},
+		{
+			name:     `slices of structs`,
+			change:   func(c *validateTestConfig) { c.Servers = append(c.Servers, validateTestServer{}) },
+			expected: []string{`config/database.servers[1].host : cannot be empty`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := validTestConfig()
+			test.change(&c)
+
+			err := Validate(`config/database`, &c)
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Errorf(`expected no error, got %v`, err)
+				}
+				return
+			}
+
+			errs, ok := err.(Errors)
+			if !ok || len(errs) != len(test.expected) {
+				t.Fatalf(`expected %d errors, got %v`, len(test.expected), err)
+			}
+			for i, expected := range test.expected {
+				if errs[i].Error() != expected {
+					t.Errorf(`expected %q, got %q`, expected, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	c := struct {
+		Port int  `yaml:"port" validate:"min=low"`
+		On   bool `yaml:"on" validate:"max=1"`
+		Name int  `yaml:"name" validate:"unique"`
+	}{}
+
+	err := Validate(`config/app`, &c)
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf(`expected an error for each invalid rule, got %v`, err)
+	}
+
+	for i, message := range []string{`invalid rule min=low`, `not supported for bool`, `unknown rule unique`} {
+		if !strings.Contains(errs[i].Error(), message) {
+			t.Errorf(`expected %q in %q`, message, errs[i])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/danakum/go-util/log"
+)
+
+var ErrWatchNotSupported = errors.New(`config: source cannot be watched`)
+
+//Watcher Loaders which can notify when the source of a configuration changes
+type Watcher interface {
+	Watch(path string, changed func()) (stop func(), err error)
+}
+
+//Watch Reload the configuration on every change of its source. The new value is decoded into
+//a fresh instance of the type of i, validated (tags first) and then passed to the callback. When loading
+//or validation fails the change is ignored and the previous value is kept. Calling stop ends the watch
+func (c *errorConfigurator) Watch(path string, i interface{}, validator Validator, callback func(config interface{})) (func(), error) {
+	if _, err := structValue(i); err != nil {
+		return nil, err
+	}
+
+	watcher, ok := c.loader.(Watcher)
+	if !ok {
+		return nil, fmt.Errorf(`%w : %s`, ErrWatchNotSupported, path)
+	}
+
+	typ := reflect.TypeOf(i).Elem()
+	return watcher.Watch(path, func() {
+		c.reload(path, typ, validator, callback)
+	})
+}
+
+func (c *errorConfigurator) reload(path string, typ reflect.Type, validator Validator, callback func(config interface{})) {
+	next := reflect.New(typ).Interface()
+	if err := c.loader.Load(path, next); err != nil {
+		log.Error(`cannot reload configuration `+path+`, keeping the previous one`, err)
+		return
+	}
+
+	if err := ResolveSecrets(path, next); err != nil {
+		log.Error(`cannot resolve secrets of `+path+`, keeping the previous one`, err)
+		return
+	}
+
+	if err := Validate(path, next); err != nil {
+		log.Error(`invalid configuration `+path+`, keeping the previous one`, err)
+		return
+	}
+
+	if validator != nil {
+		if err := validator(next); err != nil {
+			log.Error(`invalid configuration `+path+`, keeping the previous one`, err)
+			return
+		}
+	}
+
+	log.Info(`configuration ` + path + ` reloaded`)
+	callback(next)
+}
+
+//Watch Watch every layer able to, a change of any of them reloads all layers
+func (l *LayeredLoader) Watch(path string, changed func()) (func(), error) {
+	stops := make([]func(), 0)
+	for _, layer := range l.layers {
+		watcher, ok := layer.Loader.(Watcher)
+		if !ok {
+			continue
+		}
+
+		stop, err := watcher.Watch(path, changed)
+		if err != nil {
+			if errors.Is(err, ErrWatchNotSupported) || (layer.Optional && isNotFound(err)) {
+				continue
+			}
+			for _, s := range stops {
+				s()
+			}
+			return nil, fmt.Errorf(`config: cannot watch layer %s of %s : %s`, layer.Name, path, err)
+		}
+
+		stops = append(stops, stop)
+	}
+
+	if len(stops) < 1 {
+		return nil, fmt.Errorf(`%w : %s`, ErrWatchNotSupported, path)
+	}
+
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}, nil
+}
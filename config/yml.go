@@ -1,24 +1,41 @@
 package config
 
 import (
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
+	"fmt"
+	"io/fs"
 )
 
-type YmlFileLoader struct{}
+type YmlFileLoader struct {
+	fs fs.FS
+}
 
 func NewYmlFileLoader() Loader {
 	return new(YmlFileLoader)
 }
 
-func (YmlFileLoader) Load(path string, i interface{}) error {
+//NewYmlFSLoader Read yaml files from a file system instead of the config root, ex: an embed.FS
+func NewYmlFSLoader(fsys fs.FS) Loader {
+	return &YmlFileLoader{
+		fs: fsys,
+	}
+}
+
+func (l YmlFileLoader) Load(path string, i interface{}) error {
 
-	file := path + `.yaml`
-	byt, err := ioutil.ReadFile(file)
+	byt, err := readConfigFile(l.fs, path, `.yaml`)
 	if err != nil {
 		return err
 	}
 
-	return yaml.UnmarshalStrict(byt, i)
+	return decodeConfigFile(path, byt, i)
+
+}
+
+//Watch Notify when the yaml file behind a path or its profile file changes
+func (l YmlFileLoader) Watch(path string, changed func()) (func(), error) {
+	if l.fs != nil {
+		return nil, fmt.Errorf(`%w : %s is read from a file system`, ErrWatchNotSupported, path)
+	}
 
+	return watchConfigFile(path, `.yaml`, changed)
 }
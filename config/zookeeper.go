@@ -1,40 +1,30 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/danakum/go-util/log"
+	"github.com/danakum/go-util/zookeeper"
 	"github.com/samuel/go-zookeeper/zk"
 	"gopkg.in/yaml.v2"
-	"io/ioutil"
 	"os"
-	"strings"
+	"reflect"
+	"sync"
 	"time"
 )
 
-type ZookeeperLoader struct{}
+//ZookeeperLoader Read configurations from the znode ZK_CONFIG_PATH + path through the shared
+//zookeeper client when ZK_CONFIG is true, and from the local yaml files otherwise.
+//Nodes can hold json or yaml, Strict rejects unknown fields for both
+type ZookeeperLoader struct {
+	Strict bool
+}
 
 func NewZookeeperLoader() Loader {
 	return new(ZookeeperLoader)
 }
 
-var (
-	zkCon *zk.Conn
-)
-
-func init() {
-	log.Info(`Zookeeper connected up`)
-	log.Info(os.Getenv(`ZK_CONFIG`))
-	if os.Getenv(`ZK_CONFIG`) == `true` {
-		c, _, err := zk.Connect(strings.Split(os.Getenv(`ZK_HOSTS`), `,`), time.Second) //*10)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		log.Info(`Zookeeper connected`)
-		zkCon = c
-	}
-}
-
 func (l *ZookeeperLoader) Load(path string, i interface{}) error {
 
 	if os.Getenv(`ZK_CONFIG`) == `true` {
@@ -47,28 +37,160 @@ func (l *ZookeeperLoader) Load(path string, i interface{}) error {
 
 func (l *ZookeeperLoader) fromFile(path string, i interface{}) error {
 
-	byt, err := ioutil.ReadFile(path + `.yaml`)
+	byt, err := readConfigFile(nil, path, `.yaml`)
 	if err != nil {
-		log.Fatal(`cannot read file `+path, err)
+		return fmt.Errorf(`cannot read file %s : %w`, path, err)
 	}
 
 	if err := yaml.UnmarshalStrict(byt, i); err != nil {
-		log.Error(err)
+		return fmt.Errorf(`cannot decode file %s : %w`, path, err)
 	}
 
-	return err
+	return nil
 }
 
 func (l *ZookeeperLoader) fromZookeeper(path string, i interface{}) error {
 
-	byt, _, err := zkCon.Get("/" + path)
+	zkCon, err := zookeeper.Client()
+	if err != nil {
+		return err
+	}
+
+	byt, _, err := zkCon.Get(zookeeper.Path(path))
 	if err != nil {
-		log.Fatal(`zookeeper cannot read path `+path, err)
+		return fmt.Errorf(`zookeeper cannot read path %s : %w`, path, err)
 	}
 
-	if err := json.Unmarshal(byt, i); err != nil {
-		log.Error(err)
+	if byt, err = DecryptConfig(path, byt); err != nil {
+		return fmt.Errorf(`zookeeper cannot decrypt path %s : %w`, path, err)
 	}
 
-	return err
+	if err := decodeDocument(byt, i, l.Strict); err != nil {
+		return fmt.Errorf(`zookeeper cannot decode path %s : %w`, path, err)
+	}
+
+	return nil
+}
+
+//decodeDocument Decode json or yaml, detected from the first character of the document. Documents
+//looking like json are decoded as yaml when they are not json, yaml flow mappings start with { too.
+//i is only updated when a decoding succeeds, a failed json attempt leaves nothing behind for the yaml one
+func decodeDocument(byt []byte, i interface{}, strict bool) error {
+	trimmed := bytes.TrimSpace(byt)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		err := decodeCopy(i, func(c interface{}) error {
+			decoder := json.NewDecoder(bytes.NewReader(trimmed))
+			if strict {
+				decoder.DisallowUnknownFields()
+			}
+			return decoder.Decode(c)
+		})
+		if err == nil {
+			return nil
+		}
+
+		if decodeCopy(i, func(c interface{}) error { return decodeYaml(byt, c, strict) }) == nil {
+			return nil
+		}
+
+		return err
+	}
+
+	return decodeCopy(i, func(c interface{}) error { return decodeYaml(byt, c, strict) })
+}
+
+//decodeCopy Decode into a copy of the value i points to, which replaces it on success
+func decodeCopy(i interface{}, decode func(c interface{}) error) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return decode(i)
+	}
+
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(deepCopy(v.Elem()))
+	if err := decode(c.Interface()); err != nil {
+		return err
+	}
+
+	v.Elem().Set(c.Elem())
+	return nil
+}
+
+func decodeYaml(byt []byte, i interface{}, strict bool) error {
+	if strict {
+		return yaml.UnmarshalStrict(byt, i)
+	}
+
+	return yaml.Unmarshal(byt, i)
+}
+
+//Watch Notify on every change of the znode behind a path. Watches are re-armed after each
+//event, and a lost session is reported as a change since updates may have been missed.
+//A deleted znode is watched for its creation until it comes back.
+//When zookeeper is disabled the local yaml file is watched instead
+func (l *ZookeeperLoader) Watch(path string, changed func()) (func(), error) {
+	if os.Getenv(`ZK_CONFIG`) != `true` {
+		return watchConfigFile(path, `.yaml`, changed)
+	}
+
+	zkCon, err := zookeeper.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	node := zookeeper.Path(os.Getenv(`ZK_CONFIG_PATH`) + path)
+	events, err := watchNode(zkCon, node)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case event := <-events:
+				for {
+					events, err = watchNode(zkCon, node)
+					if err == nil {
+						break
+					}
+
+					log.Error(`zookeeper cannot watch path `+node, err)
+					select {
+					case <-stop:
+						return
+					case <-time.After(time.Second):
+					}
+				}
+
+				if event.Type == zk.EventNodeDataChanged || event.Type == zk.EventNodeCreated || event.Type == zk.EventNotWatching {
+					changed()
+				}
+			}
+		}
+	}()
+
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}, nil
+}
+
+//watchNode Watch the data of a znode, or its creation while it does not exist
+func watchNode(zkCon *zk.Conn, node string) (<-chan zk.Event, error) {
+	for {
+		_, _, events, err := zkCon.GetW(node)
+		if err != zk.ErrNoNode {
+			return events, err
+		}
+
+		exists, _, events, err := zkCon.ExistsW(node)
+		if err != nil || !exists {
+			return events, err
+		}
+	}
 }
@@ -0,0 +1,42 @@
+package config
+
+import "testing"
+
+type documentTestConfig struct {
+	Host string `yaml:"host" json:"host"`
+	Port int    `yaml:"port" json:"port"`
+}
+
+func TestDecodeDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		strict   bool
+		expected documentTestConfig
+		err      bool
+	}{
+		{name: `json`, document: `{"host":"db","port":5432}`, expected: documentTestConfig{Host: `db`, Port: 5432}},
+		{name: `yaml`, document: "host: db\nport: 5432\n", expected: documentTestConfig{Host: `db`, Port: 5432}},
+		{name: `yaml flow mapping`, document: `{host: db, port: 5432}`, expected: documentTestConfig{Host: `db`, Port: 5432}},
+		{name: `json unknown field`, document: `{"host":"db","user":"app"}`, expected: documentTestConfig{Host: `db`, Port: 1}},
+		{name: `strict json unknown field`, document: `{"host":"db","user":"app"}`, strict: true, expected: documentTestConfig{Port: 1}, err: true},
+		{name: `strict yaml unknown field`, document: "host: db\nuser: app\n", strict: true, expected: documentTestConfig{Port: 1}, err: true},
+		{name: `invalid`, document: `{"host": [}`, expected: documentTestConfig{Port: 1}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			//the port set by a previous layer is kept, and nothing is changed when decoding fails
+			c := documentTestConfig{Port: 1}
+
+			err := decodeDocument([]byte(test.document), &c, test.strict)
+			if (err != nil) != test.err {
+				t.Fatalf(`expected error %v, got %v`, test.err, err)
+			}
+
+			if c != test.expected {
+				t.Errorf(`expected %+v, got %+v`, test.expected, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package featureflag
+
+import (
+	"context"
+	"hash/fnv"
+
+	tctx "github.com/danakum/go-util/traceable_context"
+)
+
+//Flag Rules of a feature flag
+//
+//	enabled: false            the flag is off for everyone
+//	targets: {tenant: [a, b]} on for contexts with one of the listed attribute values
+//	percentage: 25            on for a stable share of the values of attribute
+//	attribute: user
+//
+//An enabled flag without targets nor percentage is on for everyone
+type Flag struct {
+	Enabled    bool                `yaml:"enabled" json:"enabled"`
+	Percentage *float64            `yaml:"percentage" json:"percentage,omitempty"`
+	Attribute  string              `yaml:"attribute" json:"attribute,omitempty"`
+	Targets    map[string][]string `yaml:"targets" json:"targets,omitempty"`
+}
+
+//Attributes Properties of the current request flags are evaluated against (tenant, user, country...)
+type Attributes map[string]string
+
+var attributesKey = `featureflag: Attributes`
+
+//WithAttributes Add attributes to a context, on top of the ones it already carries
+func WithAttributes(ctx context.Context, attributes Attributes) context.Context {
+	merged := Attributes{}
+	for key, value := range AttributesFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range attributes {
+		merged[key] = value
+	}
+
+	if _, ok := ctx.(tctx.TraceableContext); ok {
+		return tctx.WithValue(ctx, &attributesKey, merged)
+	}
+
+	return context.WithValue(ctx, &attributesKey, merged)
+}
+
+func AttributesFromContext(ctx context.Context) Attributes {
+	attributes, _ := ctx.Value(&attributesKey).(Attributes)
+	return attributes
+}
+
+//Evaluate Whether the flag is on for the attributes of a context
+func (f Flag) Evaluate(ctx context.Context, name string) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	attributes := AttributesFromContext(ctx)
+
+	for attribute, values := range f.Targets {
+		for _, value := range values {
+			if attributes[attribute] == value {
+				return true
+			}
+		}
+	}
+
+	if f.Percentage == nil {
+		return len(f.Targets) < 1
+	}
+
+	value, ok := attributes[f.Attribute]
+	if !ok {
+		return false
+	}
+
+	return bucket(name, value) < *f.Percentage
+}
+
+//bucket Stable position of a value in [0, 100) for a flag, so each flag rolls out to a different share
+func bucket(name string, value string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name + `:` + value))
+
+	return float64(h.Sum32()%10000) / 100
+}
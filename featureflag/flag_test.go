@@ -0,0 +1,102 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func percentage(p float64) *float64 {
+	return &p
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := WithAttributes(context.Background(), Attributes{`tenant`: `acme`, `user`: `u1`})
+
+	tests := []struct {
+		name     string
+		flag     Flag
+		expected bool
+	}{
+		{name: `disabled`, flag: Flag{Targets: map[string][]string{`tenant`: {`acme`}}}, expected: false},
+		{name: `enabled for everyone`, flag: Flag{Enabled: true}, expected: true},
+		{name: `targeted`, flag: Flag{Enabled: true, Targets: map[string][]string{`tenant`: {`other`, `acme`}}}, expected: true},
+		{name: `not targeted`, flag: Flag{Enabled: true, Targets: map[string][]string{`tenant`: {`other`}}}, expected: false},
+		{name: `full rollout`, flag: Flag{Enabled: true, Percentage: percentage(100), Attribute: `user`}, expected: true},
+		{name: `no rollout`, flag: Flag{Enabled: true, Percentage: percentage(0), Attribute: `user`}, expected: false},
+		{name: `rollout without the attribute`, flag: Flag{Enabled: true, Percentage: percentage(100), Attribute: `country`}, expected: false},
+		{
+			name:     `targets before rollout`,
+			flag:     Flag{Enabled: true, Percentage: percentage(0), Attribute: `user`, Targets: map[string][]string{`tenant`: {`acme`}}},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.flag.Evaluate(ctx, `new_checkout`); got != test.expected {
+				t.Errorf(`expected %v, got %v`, test.expected, got)
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	//the same value always lands in the same bucket
+	if bucket(`new_checkout`, `u1`) != bucket(`new_checkout`, `u1`) {
+		t.Error(`expected a stable bucket`)
+	}
+
+	tests := []struct {
+		percentage float64
+	}{
+		{percentage: 10},
+		{percentage: 25},
+		{percentage: 50},
+	}
+
+	const users = 10000
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.percentage), func(t *testing.T) {
+			on, onBoth := 0, 0
+			for i := 0; i < users; i++ {
+				user := fmt.Sprintf(`user-%d`, i)
+
+				first := bucket(`new_checkout`, user) < test.percentage
+				second := bucket(`new_search`, user) < test.percentage
+				if first {
+					on++
+				}
+				if first && second {
+					onBoth++
+				}
+			}
+
+			share := float64(on) * 100 / users
+			if math.Abs(share-test.percentage) > 2 {
+				t.Errorf(`expected about %v%% of the users, got %v%%`, test.percentage, share)
+			}
+
+			//flags are hashed with their name, each one rolls out to a different share of users
+			both := float64(onBoth) * 100 / users
+			if expected := test.percentage * test.percentage / 100; math.Abs(both-expected) > 2 {
+				t.Errorf(`expected about %v%% of the users on both flags, got %v%%`, expected, both)
+			}
+		})
+	}
+}
+
+func TestWithAttributes(t *testing.T) {
+	ctx := WithAttributes(context.Background(), Attributes{`tenant`: `acme`, `user`: `u1`})
+	ctx = WithAttributes(ctx, Attributes{`user`: `u2`})
+
+	attributes := AttributesFromContext(ctx)
+	if attributes[`tenant`] != `acme` || attributes[`user`] != `u2` {
+		t.Errorf(`expected attributes to be merged, got %v`, attributes)
+	}
+
+	if AttributesFromContext(context.Background()) != nil {
+		t.Error(`expected no attributes on a bare context`)
+	}
+}
@@ -0,0 +1,195 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/log"
+	"gopkg.in/yaml.v2"
+)
+
+//Config Flags of a configuration keyed by flag name
+//
+//	flags:
+//	  new_checkout:
+//	    enabled: true
+//	    percentage: 10
+//	    attribute: user
+//	    targets:
+//	      tenant: [acme]
+type Config struct {
+	Flags map[string]Flag `yaml:"flags" json:"flags"`
+}
+
+//Set Flags loaded from a configuration source, kept up to date while the source can be watched
+type Set struct {
+	mu        *sync.RWMutex
+	flags     map[string]Flag
+	overrides map[string]bool
+}
+
+//Default Set behind the package level functions, loaded from config/features by Init
+var Default = New()
+
+func init() {
+	config.RegisterType(`config/features`, Config{})
+}
+
+func New() *Set {
+	return &Set{
+		mu:        &sync.RWMutex{},
+		flags:     make(map[string]Flag),
+		overrides: make(map[string]bool),
+	}
+}
+
+//NewFromLoader Create a Set backed by any config.Loader
+func NewFromLoader(loader config.Loader, path string) (*Set, error) {
+	s := New()
+	return s, s.Load(config.NewErrorConfigurator(loader), path)
+}
+
+//Init Load the Default set from config/features. Overrides are read from the file named by
+//FEATURE_FLAGS_OVERRIDE when it is set
+func Init() {
+	if err := Default.Load(config.DefaultErrorConfigurator, `config/features`); err != nil {
+		log.Fatal(`Cannot load feature flags`, err)
+	}
+
+	if file := os.Getenv(`FEATURE_FLAGS_OVERRIDE`); file != `` {
+		if err := Default.LoadOverrides(file); err != nil {
+			log.Fatal(`Cannot load feature flag overrides`, err)
+		}
+	}
+}
+
+//Load Load the flags of a path and follow its changes when the source supports watching.
+//Sources which cannot be watched keep the flags loaded at startup
+func (s *Set) Load(configurator config.ErrorConfigurator, path string) error {
+	conf := Config{}
+	if err := configurator.Load(path, &conf, validateConfig); err != nil {
+		return err
+	}
+	s.replace(conf.Flags)
+
+	_, err := configurator.Watch(path, &Config{}, validateConfig, func(c interface{}) {
+		s.replace(c.(*Config).Flags)
+	})
+	if errors.Is(err, config.ErrWatchNotSupported) {
+		log.Info(`feature flags of ` + path + ` will not be reloaded, source cannot be watched`)
+		return nil
+	}
+
+	return err
+}
+
+func (s *Set) replace(flags map[string]Flag) {
+	if flags == nil {
+		flags = make(map[string]Flag)
+	}
+
+	s.mu.Lock()
+	s.flags = flags
+	s.mu.Unlock()
+}
+
+//Enabled Whether a flag is on for the attributes of a context. Unknown flags are off
+func (s *Set) Enabled(ctx context.Context, name string) bool {
+	s.mu.RLock()
+	enabled, overridden := s.overrides[name]
+	flag, ok := s.flags[name]
+	s.mu.RUnlock()
+
+	if overridden {
+		return enabled
+	}
+
+	if !ok {
+		return false
+	}
+
+	return flag.Evaluate(ctx, name)
+}
+
+//Flag Rules of a flag as currently loaded
+func (s *Set) Flag(name string) (Flag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flag, ok := s.flags[name]
+	return flag, ok
+}
+
+//Override Force a flag on or off whatever its rules, meant for tests and local runs
+func (s *Set) Override(name string, enabled bool) {
+	s.mu.Lock()
+	s.overrides[name] = enabled
+	s.mu.Unlock()
+}
+
+//LoadOverrides Force flags from a yaml or json file of flag names to booleans (new_checkout: true)
+func (s *Set) LoadOverrides(file string) error {
+	byt, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf(`featureflag: cannot read overrides %s : %w`, file, err)
+	}
+
+	overrides := make(map[string]bool)
+	if err := yaml.Unmarshal(byt, &overrides); err != nil {
+		return fmt.Errorf(`featureflag: cannot parse overrides %s : %w`, file, err)
+	}
+
+	s.mu.Lock()
+	for name, enabled := range overrides {
+		s.overrides[name] = enabled
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+//ResetOverrides Remove every override, flags are evaluated from their rules again
+func (s *Set) ResetOverrides() {
+	s.mu.Lock()
+	s.overrides = make(map[string]bool)
+	s.mu.Unlock()
+}
+
+func validateConfig(c interface{}) error {
+	errs := make(config.Errors, 0)
+	for name, flag := range c.(*Config).Flags {
+		if flag.Percentage == nil {
+			continue
+		}
+
+		if *flag.Percentage < 0 || *flag.Percentage > 100 {
+			errs = errs.Append(fmt.Errorf(`featureflag: %s : percentage should be between 0 and 100`, name))
+		}
+
+		if flag.Attribute == `` {
+			errs = errs.Append(fmt.Errorf(`featureflag: %s : percentage rollout needs an attribute`, name))
+		}
+	}
+
+	return errs.Err()
+}
+
+//Enabled Whether a flag of the Default set is on for the attributes of a context
+func Enabled(ctx context.Context, name string) bool {
+	return Default.Enabled(ctx, name)
+}
+
+//Override Force a flag of the Default set on or off
+func Override(name string, enabled bool) {
+	Default.Override(name, enabled)
+}
+
+//LoadOverrides Force flags of the Default set from a file
+func LoadOverrides(file string) error {
+	return Default.LoadOverrides(file)
+}
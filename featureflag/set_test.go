@@ -0,0 +1,90 @@
+package featureflag
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/danakum/go-util/config"
+)
+
+func TestSet(t *testing.T) {
+	files := fstest.MapFS{
+		`config/features.yaml`: {Data: []byte("flags:\n  new_checkout:\n    enabled: true\n  new_search:\n    enabled: false\n")},
+	}
+
+	s, err := NewFromLoader(config.NewYmlFSLoader(files), `config/features`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := filepath.Join(t.TempDir(), `overrides.yaml`)
+	if err := ioutil.WriteFile(overrides, []byte("new_search: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		change   func()
+		expected map[string]bool
+	}{
+		{
+			name:     `rules`,
+			change:   func() {},
+			expected: map[string]bool{`new_checkout`: true, `new_search`: false, `unknown`: false},
+		},
+		{
+			name:     `override`,
+			change:   func() { s.Override(`new_checkout`, false) },
+			expected: map[string]bool{`new_checkout`: false, `new_search`: false},
+		},
+		{
+			name: `overrides file`,
+			change: func() {
+				if err := s.LoadOverrides(overrides); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expected: map[string]bool{`new_checkout`: false, `new_search`: true},
+		},
+		{
+			name:     `reset`,
+			change:   s.ResetOverrides,
+			expected: map[string]bool{`new_checkout`: true, `new_search`: false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.change()
+
+			for name, expected := range test.expected {
+				if got := s.Enabled(context.Background(), name); got != expected {
+					t.Errorf(`expected %s to be %v, got %v`, name, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSetInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{name: `percentage out of range`, document: "flags:\n  new_checkout:\n    enabled: true\n    percentage: 120\n    attribute: user\n"},
+		{name: `percentage without attribute`, document: "flags:\n  new_checkout:\n    enabled: true\n    percentage: 10\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := fstest.MapFS{`config/features.yaml`: {Data: []byte(test.document)}}
+
+			if _, err := NewFromLoader(config.NewYmlFSLoader(files), `config/features`); err == nil {
+				t.Error(`expected an invalid flag to be rejected`)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+//Package health Liveness and readiness endpoints aggregating the checks registered by the
+//clients of the application (mysql, postgre, redis, mongo, mqtt, zookeeper) and by the service
+//
+//	/healthz runs the liveness checks, a failure means the process should be restarted
+//	/readyz  runs every check, a failure means the instance should not receive traffic
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = `ok`
+	StatusFail = `fail`
+)
+
+var (
+	//DefaultTimeout Timeout of checks registered without one
+	DefaultTimeout = 2 * time.Second
+	//DefaultCacheTTL How long the result of checks registered without a ttl is reused
+	DefaultCacheTTL = time.Second
+)
+
+//Check A named probe of a dependency. The check func gets a context bound to Timeout
+type Check struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Timeout  time.Duration
+	CacheTTL time.Duration
+	Liveness bool //also run by /healthz
+}
+
+//Result Outcome of a check
+type Result struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Duration string    `json:"duration"`
+	Time     time.Time `json:"time"`
+	Cached   bool      `json:"cached,omitempty"`
+}
+
+//Report Aggregated results, ok when every check passed
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	Check
+	mu     *sync.Mutex
+	result *Result
+}
+
+type Registry struct {
+	mu     *sync.RWMutex
+	checks map[string]*check
+}
+
+//Default Registry the clients register their checks with
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:     &sync.RWMutex{},
+		checks: make(map[string]*check),
+	}
+}
+
+//Register Add a check, registering a name twice replaces the previous check
+func (r *Registry) Register(c Check) {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = DefaultCacheTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[c.Name] = &check{Check: c, mu: &sync.Mutex{}}
+}
+
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.checks, name)
+}
+
+//Run Run the checks concurrently, only the liveness ones when liveness is true
+func (r *Registry) Run(ctx context.Context, liveness bool) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if !liveness || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+
+	results := make([]Result, len(checks))
+	wg := &sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(checks)),
+	}
+	for i, c := range checks {
+		report.Checks[c.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+//run Run a check or reuse its last result while it is fresh. Concurrent probes wait for a
+//single run instead of piling up on the dependency
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.result != nil && time.Since(c.result.Time) < c.CacheTTL {
+		cached := *c.result
+		cached.Cached = true
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	begin := time.Now()
+	err := call(ctx, c.Check.Check)
+
+	result := Result{
+		Status:   StatusOK,
+		Duration: time.Since(begin).String(),
+		Time:     begin,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	c.result = &result
+	return result
+}
+
+//call Run a check func, giving up when ctx is done even if the func ignores it
+func call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf(`check panicked : %v`, r)
+			}
+		}()
+		result <- fn(ctx)
+	}()
+
+	select {
+	case err = <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf(`check timed out : %w`, ctx.Err())
+	}
+}
+
+//Healthz Handler of /healthz
+func (r *Registry) Healthz() http.Handler {
+	return r.handler(true)
+}
+
+//Readyz Handler of /readyz
+func (r *Registry) Readyz() http.Handler {
+	return r.handler(false)
+}
+
+//Mount Serve /healthz and /readyz on a mux
+func (r *Registry) Mount(mux *http.ServeMux) {
+	mux.Handle(`/healthz`, r.Healthz())
+	mux.Handle(`/readyz`, r.Readyz())
+}
+
+func (r *Registry) handler(liveness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context(), liveness)
+
+		w.Header().Set(`Content-Type`, `application/json`)
+		w.Header().Set(`Cache-Control`, `no-store`)
+		if report.Status != StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+//Register Add a check to the Default registry
+func Register(c Check) {
+	Default.Register(c)
+}
+
+//Unregister Remove a check from the Default registry
+func Unregister(name string) {
+	Default.Unregister(name)
+}
+
+//Mount Serve /healthz and /readyz of the Default registry on a mux
+func Mount(mux *http.ServeMux) {
+	Default.Mount(mux)
+}
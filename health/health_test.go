@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: `mysql`, Check: func(ctx context.Context) error { return nil }, Liveness: true})
+	r.Register(Check{Name: `redis`, Check: func(ctx context.Context) error { return errors.New(`connection refused`) }})
+
+	tests := []struct {
+		name     string
+		liveness bool
+		status   string
+		checks   []string
+	}{
+		{name: `readiness`, status: StatusFail, checks: []string{`mysql`, `redis`}},
+		{name: `liveness`, liveness: true, status: StatusOK, checks: []string{`mysql`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := r.Run(context.Background(), test.liveness)
+
+			if report.Status != test.status {
+				t.Errorf(`expected status %s, got %s`, test.status, report.Status)
+			}
+			if len(report.Checks) != len(test.checks) {
+				t.Fatalf(`expected checks %v, got %+v`, test.checks, report.Checks)
+			}
+			for _, name := range test.checks {
+				if _, ok := report.Checks[name]; !ok {
+					t.Errorf(`expected a result of %s`, name)
+				}
+			}
+		})
+	}
+
+	if got := r.Run(context.Background(), false).Checks[`redis`].Error; got != `connection refused` {
+		t.Errorf(`expected the error of the check, got %q`, got)
+	}
+}
+
+func TestRunCached(t *testing.T) {
+	calls := int32(0)
+
+	r := NewRegistry()
+	r.Register(Check{
+		Name: `mysql`,
+		Check: func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		},
+		CacheTTL: 50 * time.Millisecond,
+	})
+
+	first := r.Run(context.Background(), false).Checks[`mysql`]
+	second := r.Run(context.Background(), false).Checks[`mysql`]
+	if first.Cached || !second.Cached || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf(`expected the second run to reuse the first result, got %d calls`, calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if r.Run(context.Background(), false).Checks[`mysql`].Cached || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf(`expected the check to run again once the result expired, got %d calls`, calls)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{
+		Name: `ignores context`,
+		Check: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+		Timeout: 20 * time.Millisecond,
+	})
+	r.Register(Check{
+		Name: `panics`,
+		Check: func(ctx context.Context) error {
+			panic(`boom`)
+		},
+	})
+
+	begin := time.Now()
+	report := r.Run(context.Background(), false)
+	if time.Since(begin) > 500*time.Millisecond {
+		t.Errorf(`expected a check ignoring its context to be given up, took %s`, time.Since(begin))
+	}
+
+	for name, message := range map[string]string{
+		`ignores context`: `timed out`,
+		`panics`:          `panicked`,
+	} {
+		result := report.Checks[name]
+		if result.Status != StatusFail || !strings.Contains(result.Error, message) {
+			t.Errorf(`expected %s to fail with %s, got %+v`, name, message, result)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: `mysql`, Check: func(ctx context.Context) error { return nil }, Liveness: true})
+	r.Register(Check{Name: `redis`, Check: func(ctx context.Context) error { return errors.New(`down`) }})
+
+	mux := http.NewServeMux()
+	r.Mount(mux)
+
+	for path, status := range map[string]int{
+		`/healthz`: http.StatusOK,
+		`/readyz`:  http.StatusServiceUnavailable,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != status {
+			t.Errorf(`expected %d from %s, got %d`, status, path, w.Code)
+		}
+		if w.Header().Get(`Content-Type`) != `application/json` {
+			t.Errorf(`expected a json report from %s`, path)
+		}
+	}
+}
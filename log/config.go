@@ -2,8 +2,8 @@ package log
 
 
 import (
+	"github.com/danakum/go-util/config/configfs"
 	"gopkg.in/yaml.v2"
-	"io/ioutil"
 	"log"
 	"os"
 )
@@ -13,42 +13,63 @@ type LogConfig struct {
 	RemoteLogging bool   `yaml:"remote_logging" json:"remote_logging"`
 	FilePath      bool   `yaml:"file_path_enabled" json:"file_path_enabled"`
 	Colors        bool   `yaml:"colors" json:"colors"`
+	Format        string `yaml:"format" json:"format" default:"text" validate:"oneof=text json"` //text or json, one object per line
 }
 
 var Config *LogConfig
 
 func init() {
-	setDefaults()
-	loadConfig()
+	Init()
+	configfs.OnChange(Init)
+}
+
+//Init Load the logging configuration from config/logger.yaml and the LOG_* environment variables.
+//It runs again whenever configfs.SetRoot or configfs.SetDefaults change where the file is read from
+func Init() {
+	c := defaultConfig()
+	loadConfig(c)
 
 	if os.Getenv(`LOG_LEVEL`) != `` {
-		Config.Level = os.Getenv(`LOG_LEVEL`)
+		c.Level = os.Getenv(`LOG_LEVEL`)
 	}
 
 	if os.Getenv(`LOG_FILE_PATH`) != `` && os.Getenv(`LOG_FILE_PATH`) == `1` {
-		Config.FilePath = true
+		c.FilePath = true
+	}
+
+	if os.Getenv(`LOG_FORMAT`) != `` {
+		c.Format = os.Getenv(`LOG_FORMAT`)
 	}
 
+	if c.Format != FormatText && c.Format != FormatJSON {
+		log.Println(`go-util/log: unknown log format ` + c.Format + `, expected text or json, using text`)
+		c.Format = FormatText
+	}
+
+	Config = c
 }
 
-func setDefaults() {
-	Config = new(LogConfig)
-	Config.Level = `INFO`
-	Config.RemoteLogging = false
-	Config.Colors = true
-	Config.FilePath = true
+func defaultConfig() *LogConfig {
+	c := new(LogConfig)
+	c.Level = `INFO`
+	c.RemoteLogging = false
+	c.Colors = true
+	c.FilePath = true
+	c.Format = FormatText
+
+	return c
 }
 
 //loadConfig Load logging configurations
-func loadConfig() {
+func loadConfig(c *LogConfig) {
 
-	file, err := ioutil.ReadFile(`config/logger.yaml`)
+	file, err := configfs.ReadFile(`config/logger.yaml`)
 	if err != nil {
 		log.Println(`go-util/log: Cannot open config file `, err)
 		return
 	}
 
-	err = yaml.Unmarshal(file, &Config)
+	err = yaml.Unmarshal(file, c)
 	if err != nil {
 		log.Fatalln(`go-util/log: Cannot decode config file `, err)
 	}
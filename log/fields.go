@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	context2 "github.com/danakum/util/traceable_context"
+	"github.com/google/uuid"
+)
+
+//Field A typed key value pair added to log entries
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//F Create a field, to mix with key value pairs in With
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+var fieldsKey = `log: fields`
+
+//fieldsValue Fields of a context along with the trace uuid of the context they were added to,
+//so entries keep the uuid even when the traceable context is wrapped
+type fieldsValue struct {
+	uuid   uuid.UUID
+	fields []Field
+}
+
+//With Context carrying fields logged by every *Context call made with it or with a context derived
+//from it. Takes key value pairs or Field values, fields of the parent context are kept and
+//overridden by keys set again
+//
+//	ctx = log.With(ctx, `order_id`, order.ID, `tenant`, tenant)
+//	log.InfoContext(ctx, `order placed`)
+func With(ctx context.Context, kv ...interface{}) context.Context {
+	parent := valueFromContext(ctx)
+
+	fields := make([]Field, 0, len(parent.fields)+len(kv)/2)
+	fields = append(fields, parent.fields...)
+
+	for i := 0; i < len(kv); i++ {
+		var field Field
+		switch key := kv[i].(type) {
+		case Field:
+			field = key
+		case string:
+			field = Field{Key: key, Value: `!MISSING`}
+			if i+1 < len(kv) {
+				field.Value = kv[i+1]
+				i++
+			}
+		default:
+			field = Field{Key: `!BADKEY`, Value: key}
+		}
+
+		fields = setField(fields, field)
+	}
+
+	return context.WithValue(ctx, &fieldsKey, fieldsValue{
+		uuid:   uuidFromContext(ctx),
+		fields: fields,
+	})
+}
+
+//Fields Fields carried by a context
+func Fields(ctx context.Context) []Field {
+	return append([]Field{}, valueFromContext(ctx).fields...)
+}
+
+func setField(fields []Field, field Field) []Field {
+	for i := range fields {
+		if fields[i].Key == field.Key {
+			fields[i] = field
+			return fields
+		}
+	}
+
+	return append(fields, field)
+}
+
+func valueFromContext(ctx context.Context) fieldsValue {
+	if ctx == nil {
+		return fieldsValue{}
+	}
+
+	value, _ := ctx.Value(&fieldsKey).(fieldsValue)
+	return value
+}
+
+//uuidFromContext Trace uuid of a context, a new one when the context is not traced
+func uuidFromContext(ctx context.Context) uuid.UUID {
+	if ctx == nil {
+		return uuid.New()
+	}
+
+	if traceableCtx, ok := ctx.(context2.TraceableContext); ok {
+		return traceableCtx.UUID()
+	}
+
+	if value := valueFromContext(ctx); value.uuid != uuid.Nil {
+		return value.uuid
+	}
+
+	return uuid.New()
+}
+
+//formatFields Fields as key=value pairs for the text format
+func formatFields(fields []Field) string {
+	pairs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		pairs = append(pairs, fmt.Sprintf(`%s=%+v`, field.Key, field.Value))
+	}
+
+	return `{` + strings.Join(pairs, ` `) + `}`
+}
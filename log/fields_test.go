@@ -0,0 +1,94 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		kv       []interface{}
+		expected []Field
+	}{
+		{
+			name:     `key value pairs`,
+			kv:       []interface{}{`order_id`, 42, `tenant`, `acme`},
+			expected: []Field{{Key: `order_id`, Value: 42}, {Key: `tenant`, Value: `acme`}},
+		},
+		{
+			name:     `fields`,
+			kv:       []interface{}{F(`order_id`, 42), `tenant`, `acme`},
+			expected: []Field{{Key: `order_id`, Value: 42}, {Key: `tenant`, Value: `acme`}},
+		},
+		{
+			name:     `missing value`,
+			kv:       []interface{}{`order_id`},
+			expected: []Field{{Key: `order_id`, Value: `!MISSING`}},
+		},
+		{
+			name:     `bad key`,
+			kv:       []interface{}{42},
+			expected: []Field{{Key: `!BADKEY`, Value: 42}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Fields(With(context.Background(), test.kv...)); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf(`expected %v, got %v`, test.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithParent(t *testing.T) {
+	parent := With(context.Background(), `order_id`, 42, `tenant`, `acme`)
+	child := With(parent, `tenant`, `globex`, `user`, `u1`)
+
+	expected := []Field{{Key: `order_id`, Value: 42}, {Key: `tenant`, Value: `globex`}, {Key: `user`, Value: `u1`}}
+	if got := Fields(child); !reflect.DeepEqual(got, expected) {
+		t.Errorf(`expected %v, got %v`, expected, got)
+	}
+
+	//the parent is left untouched
+	if got := Fields(parent); len(got) != 2 || got[1].Value != `acme` {
+		t.Errorf(`expected the parent fields to be kept, got %v`, got)
+	}
+
+	//entries logged with the contexts share the uuid of the first one
+	if uuidFromContext(child) != uuidFromContext(parent) {
+		t.Error(`expected derived contexts to keep the uuid`)
+	}
+}
+
+func TestFieldsOutput(t *testing.T) {
+	ctx := With(context.Background(), `order_id`, 42)
+
+	previous := *Config
+	Config.Level = `INFO`
+	Config.Colors = false
+	defer func() {
+		*Config = previous
+	}()
+
+	text := &bytes.Buffer{}
+	nativeLog.SetOutput(text)
+	defer nativeLog.SetOutput(os.Stdout)
+
+	InfoContext(ctx, `order placed`)
+	if !strings.Contains(text.String(), `order placed`) || !strings.Contains(text.String(), `{order_id=42}`) {
+		t.Errorf(`expected the fields in the text entry, got %s`, text)
+	}
+
+	entries := captureJSON(t, func() {
+		InfoContext(ctx, `order placed`)
+	})
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0][`fields`], map[string]interface{}{`order_id`: float64(42)}) {
+		t.Errorf(`expected the fields in the json entry, got %v`, entries)
+	}
+}
@@ -0,0 +1,153 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatText = `text`
+	FormatJSON = `json`
+)
+
+var jsonLog = log.New(os.Stdout, ``, 0)
+
+//jsonEntry Line written in the json format
+type jsonEntry struct {
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	UUID      string                 `json:"uuid"`
+	Caller    string                 `json:"caller,omitempty"`
+	Message   string                 `json:"message"`
+	Params    []interface{}          `json:"params,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+func writeJSON(logType string, uuid uuid.UUID, fields []Field, message interface{}, params ...interface{}) {
+	entry := jsonEntry{
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Level:     logType,
+		UUID:      uuid.String(),
+		Message:   mask(fmt.Sprintf(`%+v`, message)),
+		Params:    jsonParams(params),
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			entry.Fields[mask(field.Key)] = maskedValue(field.Value)
+		}
+	}
+
+	if err, ok := message.(error); ok {
+		entry.Message = mask(err.Error())
+	}
+
+	if Config.FilePath {
+		//writeEntry and writeJSON sit between the exported func and its caller
+		if _, file, line, ok := runtime.Caller(FileDepth + 2); ok {
+			entry.Caller = file + `:` + strconv.Itoa(line)
+		}
+	}
+
+	byt, err := json.Marshal(entry)
+	if err != nil {
+		byt, _ = json.Marshal(jsonEntry{
+			Timestamp: entry.Timestamp,
+			Level:     entry.Level,
+			UUID:      entry.UUID,
+			Caller:    entry.Caller,
+			Message:   entry.Message,
+			Params:    []interface{}{mask(fmt.Sprintf(`%+v`, params))},
+			Fields:    map[string]interface{}{`fields`: mask(fmt.Sprintf(`%+v`, fields))},
+		})
+	}
+
+	jsonLog.Println(string(byt))
+
+	if logType == fatal {
+		os.Exit(1)
+	}
+}
+
+//jsonParams Params as masked json values, see maskedValue
+func jsonParams(params []interface{}) []interface{} {
+	if len(params) < 1 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		values = append(values, maskedValue(param))
+	}
+
+	return values
+}
+
+//maskedValue Json value with the masked values replaced in every string it holds. Values are
+//masked before being encoded since encoding escapes characters they may contain (& => \u0026)
+func maskedValue(value interface{}) interface{} {
+	value = jsonValue(value)
+	if s, ok := value.(string); ok {
+		return mask(s)
+	}
+
+	byt, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(byt))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return value
+	}
+
+	return maskDecoded(decoded)
+}
+
+func maskDecoded(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return mask(v)
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			masked[mask(key)] = maskDecoded(item)
+		}
+		return masked
+	case []interface{}:
+		for i, item := range v {
+			v[i] = maskDecoded(item)
+		}
+		return v
+	}
+
+	return value
+}
+
+//jsonValue Errors by their message, stringers by their string and values json cannot encode as text
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprintf(`%+v`, value)
+	}
+
+	return value
+}
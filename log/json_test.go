@@ -0,0 +1,128 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//captureJSON Entries written in the json format while fn runs
+func captureJSON(t *testing.T, fn func()) []map[string]interface{} {
+	t.Helper()
+
+	previous := *Config
+	Config.Format = FormatJSON
+	Config.Level = `INFO`
+	Config.FilePath = true
+	buf := &bytes.Buffer{}
+	jsonLog.SetOutput(buf)
+	defer func() {
+		*Config = previous
+		jsonLog.SetOutput(os.Stdout)
+	}()
+
+	fn()
+
+	entries := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == `` {
+			continue
+		}
+
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf(`expected one json object per line, got %s : %s`, line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+type jsonTestStringer struct{}
+
+func (jsonTestStringer) String() string {
+	return `stringer`
+}
+
+func TestJSON(t *testing.T) {
+	entries := captureJSON(t, func() {
+		Info(`order placed`, 42, `eu`)
+		Debug(`not logged under INFO`)
+		Error(errors.New(`cannot connect`), jsonTestStringer{}, errors.New(`timeout`))
+	})
+
+	if len(entries) != 2 {
+		t.Fatalf(`expected 2 entries, got %v`, entries)
+	}
+
+	tests := []struct {
+		entry   map[string]interface{}
+		level   string
+		message string
+		params  []interface{}
+	}{
+		{entry: entries[0], level: `INFO`, message: `order placed`, params: []interface{}{float64(42), `eu`}},
+		{entry: entries[1], level: `ERROR`, message: `cannot connect`, params: []interface{}{`stringer`, `timeout`}},
+	}
+
+	for _, test := range tests {
+		if test.entry[`level`] != test.level || test.entry[`message`] != test.message {
+			t.Errorf(`expected %s %s, got %v`, test.level, test.message, test.entry)
+		}
+		if !reflect.DeepEqual(test.entry[`params`], test.params) {
+			t.Errorf(`expected params %v, got %v`, test.params, test.entry[`params`])
+		}
+		if caller, _ := test.entry[`caller`].(string); !strings.Contains(caller, `json_test.go:`) {
+			t.Errorf(`expected the caller to be the test, got %q`, caller)
+		}
+		if test.entry[`uuid`] == `` || test.entry[`timestamp`] == `` {
+			t.Errorf(`expected a uuid and a timestamp, got %v`, test.entry)
+		}
+	}
+}
+
+func TestJSONUnencodable(t *testing.T) {
+	entries := captureJSON(t, func() {
+		Info(`channel`, make(chan int))
+	})
+
+	if len(entries) != 1 {
+		t.Fatalf(`expected 1 entry, got %v`, entries)
+	}
+
+	params, _ := entries[0][`params`].([]interface{})
+	if len(params) != 1 {
+		t.Fatalf(`expected the channel to be written as text, got %v`, entries[0])
+	}
+	if _, ok := params[0].(string); !ok {
+		t.Errorf(`expected the channel to be written as text, got %v`, params[0])
+	}
+}
+
+func TestJSONMasked(t *testing.T) {
+	//json escapes some characters, masked values are replaced before encoding
+	MaskSecret(`p&ss<w>rd"\`)
+
+	entries := captureJSON(t, func() {
+		Info(`password p&ss<w>rd"\`, map[string]string{`password`: `p&ss<w>rd"\`}, []string{`p&ss<w>rd"\`})
+	})
+
+	if len(entries) != 1 {
+		t.Fatalf(`expected 1 entry, got %v`, entries)
+	}
+
+	expected := map[string]interface{}{
+		`message`: `password *****`,
+		`params`:  []interface{}{map[string]interface{}{`password`: `*****`}, []interface{}{`*****`}},
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(entries[0][key], value) {
+			t.Errorf(`expected %s to be %v, got %v`, key, value, entries[0][key])
+		}
+	}
+}
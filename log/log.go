@@ -4,7 +4,6 @@ package log
 import (
 	"context"
 	"fmt"
-	context2 "github.com/danakum/util/traceable_context"
 	"github.com/google/uuid"
 	. "github.com/logrusorgru/aurora"
 	"log"
@@ -122,35 +121,37 @@ func Fataln(message interface{}, params ...interface{}) {
 }
 
 func FatalContext(ctx context.Context, message interface{}, params interface{}) {
-	logEntry(fatal, uuid.New(), message, colored(`FATAL`), params)
+	logEntryContext(fatal, ctx, message, colored(`FATAL`), params)
 }
 
 func logEntryContext(logType string, ctx context.Context, message interface{}, color string, params ...interface{}) {
-	logEntry(logType, uuidFromContext(ctx), message, color, params...)
+	writeEntry(logType, uuidFromContext(ctx), valueFromContext(ctx).fields, message, color, params...)
 }
 
 func WithPrefix(p string, message interface{}) string {
 	return fmt.Sprintf(`%s] [%+v`, p, message)
 }
 
-func uuidFromContext(ctx context.Context) uuid.UUID {
-	traceableCtx, ok := ctx.(context2.TraceableContext)
-	if !ok {
-		return uuid.New()
-	}
-	return traceableCtx.UUID()
+func logEntry(logType string, uuid uuid.UUID, message interface{}, color string, params ...interface{}) {
+	writeEntry(logType, uuid, nil, message, color, params...)
 }
 
-func logEntry(logType string, uuid uuid.UUID, message interface{}, color string, params ...interface{}) {
+//writeEntry Format and write an entry, the caller of the exported log func is FileDepth+1 frames up
+func writeEntry(logType string, uuid uuid.UUID, fields []Field, message interface{}, color string, params ...interface{}) {
 
 	if !isLoggable(logType) {
 		return
 	}
 
+	if Config.Format == FormatJSON {
+		writeJSON(logType, uuid, fields, message, params...)
+		return
+	}
+
 	var file string
 	var line int
 	if Config.FilePath {
-		_, f, l, ok := runtime.Caller(FileDepth)
+		_, f, l, ok := runtime.Caller(FileDepth + 1)
 		if !ok {
 			f = `<Unknown>`
 			l = 1
@@ -164,14 +165,20 @@ func logEntry(logType string, uuid uuid.UUID, message interface{}, color string,
 		message = fmt.Sprintf(`[%s] [%+v]`, uuid.String(), message)
 	}
 
+	entry := toString(``, color, message, params...)
+	if len(fields) > 0 {
+		entry += ` ` + formatFields(fields)
+	}
+	entry = mask(entry)
+
 	if logType == fatal {
-		nativeLog.Fatalln(toString(``, color, message, params...))
+		nativeLog.Fatalln(entry)
 	}
 
 	if logType == err {
-		nativeLog.Println(toString(``, color, message, params...))
+		nativeLog.Println(entry)
 		return
 	}
 
-	nativeLog.Println(toString(``, color, message, params...))
+	nativeLog.Println(entry)
 }
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+//MinMaskLength Values shorter than this are not masked, they would hide unrelated parts of every line
+const MinMaskLength = 4
+
+var (
+	maskMu   = &sync.RWMutex{}
+	masked   = make(map[string]bool)
+	replacer = strings.NewReplacer()
+)
+
+//Mask Never print the given values, they are replaced with ***** in every log line.
+//Values shorter than MinMaskLength are ignored
+func Mask(values ...string) {
+	addMasks(MinMaskLength, values)
+}
+
+//MaskSecret Never print the given values whatever their length, for values known to be secrets
+//(ex: passwords resolved from secret references) which must not leak even when short
+func MaskSecret(values ...string) {
+	addMasks(1, values)
+}
+
+func addMasks(minLength int, values []string) {
+	maskMu.Lock()
+	defer maskMu.Unlock()
+
+	for _, value := range values {
+		if len(value) >= minLength {
+			masked[value] = true
+		}
+	}
+
+	//Longest values first so a value containing another one is replaced as a whole
+	sorted := make([]string, 0, len(masked))
+	for value := range masked {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	pairs := make([]string, 0, len(sorted)*2)
+	for _, value := range sorted {
+		pairs = append(pairs, value, `*****`)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+func mask(line string) string {
+	maskMu.RLock()
+	defer maskMu.RUnlock()
+
+	return replacer.Replace(line)
+}
@@ -0,0 +1,32 @@
+package log
+
+import "testing"
+
+func TestMask(t *testing.T) {
+	Mask(`long-password`, `abc`, ``)
+	MaskSecret(`xyz`)
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{line: `password long-password`, expected: `password *****`},
+		{line: `short abc is not masked`, expected: `short abc is not masked`},
+		{line: `short secret xyz`, expected: `short secret *****`},
+		{line: `nothing to mask`, expected: `nothing to mask`},
+	}
+
+	for _, test := range tests {
+		if got := mask(test.line); got != test.expected {
+			t.Errorf(`expected %q, got %q`, test.expected, got)
+		}
+	}
+}
+
+func TestMaskLongestFirst(t *testing.T) {
+	Mask(`secret`, `secret-value`)
+
+	if got := mask(`secret-value`); got != `*****` {
+		t.Errorf(`expected the longest value to be masked as a whole, got %q`, got)
+	}
+}
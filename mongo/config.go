@@ -7,17 +7,21 @@ import (
 )
 
 type Config struct {
-	Host     string `yaml:"host" json:"host"`
-	Port     int    `yaml:"port" json:"port"`
-	Database string `yaml:"database" json:"database"`
+	Host     string `yaml:"host" json:"host" validate:"required"`
+	Port     int    `yaml:"port" json:"port" validate:"min=1,max=65535"`
+	Database string `yaml:"database" json:"database" validate:"required"`
 	User     string `yaml:"user" json:"user"`
-	Password string `yaml:"password" json:"password"`
+	Password string `yaml:"password" json:"password" secret:"true"`
 	Auth     bool   `yaml:"auth" json:"auth"`
 	AuthDb   string `yaml:"auth_db" json:"auth_db"`
 }
 
 var Conf Config
 
+func init() {
+	config.RegisterType(`config/mongo`, Config{})
+}
+
 func (Config) Register() {
 	config.DefaultConfigurator.Load(`config/mongo`, &Conf, func(config interface{}) {})
 }
@@ -1,7 +1,10 @@
 package mongo
 
 import (
+	"context"
 	"fmt"
+	"github.com/danakum/go-util/app/lifecycle"
+	"github.com/danakum/go-util/health"
 	"github.com/danakum/go-util/log"
 	"gopkg.in/mgo.v2"
 )
@@ -14,7 +17,8 @@ var (
 func Init() {
 	log.Info(" Mongo host : ",Conf.Host)
 	log.Info(" Mongo Port : ",Conf.Port)
-	Session, err := mgo.Dial(fmt.Sprintf(`%s:%d`, Conf.Host, Conf.Port))
+	var err error
+	Session, err = mgo.Dial(fmt.Sprintf(`%s:%d`, Conf.Host, Conf.Port))
 
 	if err != nil {
 		log.Fatal(`Mongodb dial failed : `, err)
@@ -35,6 +39,25 @@ func Init() {
 	log.Info(`MongoDb connection initiated`)
 
 	Db = Session.DB(Conf.Database)
+
+	health.Register(health.Check{
+		Name: `mongo`,
+		Check: func(ctx context.Context) error {
+			s := Session.Copy()
+			defer s.Close()
+
+			return s.Ping()
+		},
+	})
+
+	lifecycle.Register(lifecycle.Component{
+		Name: `mongo`,
+		Stop: func(ctx context.Context) error {
+			Session.Close()
+			log.Info(`MongoDb connection closed`)
+			return nil
+		},
+	})
 }
 
 func Close(s mgo.Session) {
@@ -1,13 +1,16 @@
 package mqtt
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/danakum/go-util/app/lifecycle"
 	"github.com/danakum/go-util/error-handler"
 	"github.com/danakum/go-util/log"
 	PahoMqtt "github.com/eclipse/paho.mqtt.golang"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,6 +47,8 @@ type MqttEventHandler struct {
 	subscriptions map[*PahoMqtt.Client]subscription
 	client PahoMqtt.Client
 	mu            *sync.RWMutex
+	topics        []string
+	inFlight      int64 //events being handled
 }
 
 type subscription struct {
@@ -75,6 +80,15 @@ func NewMqttHandler(clientId string, filePath string, clusterId string) *MqttEve
 		}
 	})
 
+	//stop consuming and drain before the connection is closed
+	options := h.client.OptionsReader()
+	clientID := options.ClientID()
+	lifecycle.Register(lifecycle.Component{
+		Name:      `mqtt-consumer:` + clientID,
+		DependsOn: []string{`mqtt:` + clientID},
+		Stop:      h.Close,
+	})
+
 	return h
 }
 
@@ -115,7 +129,9 @@ func (h *MqttEventHandler) Subscribe(typ EventType, topic string, qos Qos, handl
 		log.Trace(fmt.Sprintf(`%s Received after %v miliseconds`, typ, timeTaken))
 
 		//Handle event on a separate go routine
+		atomic.AddInt64(&h.inFlight, 1)
 		go func() {
+			defer atomic.AddInt64(&h.inFlight, -1)
 			if err = handler(ev); err != nil && !error_handler.IsDomain(err) {
 				log.Error(`Mqtt Event handler failed for : `, `event`, ev.Type(), `err: `, err)
 			}
@@ -131,6 +147,7 @@ func (h *MqttEventHandler) Subscribe(typ EventType, topic string, qos Qos, handl
 			qos:     qos,
 			handler: handler,
 		}
+		h.topics = append(h.topics, topic)
 		h.mu.Unlock()
 		log.Info(`Mqtt subscription enabled for topic ` + topic)
 	}
@@ -157,3 +174,32 @@ func (h *MqttEventHandler) Publish(event Event, topic string, qos Qos, retained
 	CountProduced(h.clusterId, topic)
 	return
 }
+
+//Close Unsubscribe from every topic and wait for the events being handled, at most until ctx is done
+func (h *MqttEventHandler) Close(ctx context.Context) error {
+	h.mu.Lock()
+	topics := h.topics
+	h.topics = nil
+	currentSubscription = subscription{}
+	h.mu.Unlock()
+
+	if len(topics) > 0 {
+		if token := h.client.Unsubscribe(topics...); token.Wait() && token.Error() != nil {
+			log.Error(`Cannot unsubscribe : `, token.Error())
+		}
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&h.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(`mqtt: %d events still in flight : %w`, atomic.LoadInt64(&h.inFlight), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	log.Info(`Mqtt consumer drained`)
+	return nil
+}
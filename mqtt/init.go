@@ -1,27 +1,33 @@
 package mqtt
 
 import (
+	"context"
+	"errors"
+	"github.com/danakum/go-util/app/lifecycle"
 	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/health"
 	"github.com/danakum/go-util/log"
 	PahoMqtt "github.com/eclipse/paho.mqtt.golang"
-	"os"
-	"os/signal"
 	"time"
 )
 
 type conf struct {
-	Brokers              []string `yaml:"brokers" json:"brokers"`
+	Brokers              []string `yaml:"brokers" json:"brokers" validate:"required"`
 	ClientID             string   `yaml:"client_id" json:"client_id"`
 	User                 string   `yaml:"user" json:"user"`
-	Password             string   `yaml:"password" json:"password"`
-	PingTimeout          int      `yaml:"ping_timeout" json:"ping_timeout"`
-	MaxReconnectInterval int      `yaml:"max_reconnect_interval" json:"max_reconnect_interval"`
-	ConnectTimeout       int      `yaml:"connect_timeout" json:"connect_timeout"`
-	MessageChannelDepth  int      `yaml:"message_channel_depth" json:"message_channel_depth"`
+	Password             string   `yaml:"password" json:"password" secret:"true"`
+	PingTimeout          int      `yaml:"ping_timeout" json:"ping_timeout" validate:"min=0"`
+	MaxReconnectInterval int      `yaml:"max_reconnect_interval" json:"max_reconnect_interval" validate:"min=0"`
+	ConnectTimeout       int      `yaml:"connect_timeout" json:"connect_timeout" validate:"min=0"`
+	MessageChannelDepth  int      `yaml:"message_channel_depth" json:"message_channel_depth" validate:"min=0"`
 }
 
 //var mqttConf conf
 
+func init() {
+	config.RegisterType(`config/mqtt`, conf{})
+}
+
 func Init(clientId string, filePath string, onConnect PahoMqtt.OnConnectHandler) PahoMqtt.Client {
 	conf := parseConfig(filePath)
 	opts := PahoMqtt.NewClientOptions()
@@ -48,17 +54,26 @@ func Init(clientId string, filePath string, onConnect PahoMqtt.OnConnectHandler)
 
 	client := PahoMqtt.NewClient(opts)
 
-	go func(c PahoMqtt.Client) {
-		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, os.Interrupt)
+	health.Register(health.Check{
+		Name: `mqtt:` + opts.ClientID,
+		Check: func(ctx context.Context) error {
+			if !client.IsConnected() {
+				return errors.New(`not connected to the broker`)
+			}
+			return nil
+		},
+	})
 
-		select {
-		case sig := <-signals:
-			c.Disconnect(200)
-			log.Info(`Mqtt connection aborted : `, sig)
-			break
-		}
-	}(client)
+	//disconnect before the stores handlers write to are closed
+	lifecycle.Register(lifecycle.Component{
+		Name:      `mqtt:` + opts.ClientID,
+		DependsOn: []string{`mysql`, `postgres`, `redis`, `mongo`},
+		Stop: func(ctx context.Context) error {
+			client.Disconnect(200)
+			log.Info(`Mqtt connection closed for client `, opts.ClientID)
+			return nil
+		},
+	})
 
 	if token := client.Connect(); token.Wait() && token.Error() != nil {
 		log.Fatal(`Cannot connect to the broker : `, token.Error())
@@ -1,13 +1,16 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"github.com/danakum/go-util/app/lifecycle"
 	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/health"
 	stdMysql "github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"github.com/tryfix/log"
-	"os"
-	"os/signal"
+	"sync"
 	"time"
 )
 
@@ -55,15 +58,32 @@ func NewRWConnection()(*DbConnections){
 	con.dbConfFile = new(confFile)
 	name := uuid.New().String()
 	con.Id = name
+	connectionMu.Lock()
 	connectionMap[name] = con
+	connectionMu.Unlock()
 	return con
 }
 
 var connectionMap map[string] *DbConnections
+var connectionMu = &sync.RWMutex{}
 
+//ConnectionMap Connections created by NewRWConnection keyed by id
+func ConnectionMap() map[string]*DbConnections {
+	connectionMu.RLock()
+	defer connectionMu.RUnlock()
+
+	connections := make(map[string]*DbConnections, len(connectionMap))
+	for id, con := range connectionMap {
+		connections[id] = con
+	}
+
+	return connections
+}
 
 func GetConnection(id string)(*DbConnections){
+	connectionMu.RLock()
 	con,ok := connectionMap[id]
+	connectionMu.RUnlock()
 	if !ok{
 		log.Fatal("No database initialized under id:"+id)
 	}
@@ -76,20 +96,20 @@ func GetConnection(id string)(*DbConnections){
 var Connections DbConnections
 
 type DbConfig struct {
-	Host        string   `yaml:"host" json:"host"`                                 //Db host name
-	Port        string   `yaml:"port" json:"port"`                                 //Db Port
-	Db          string   `yaml:"database" json:"database"`                         //Db Name
-	User        string   `yaml:"user" json:"user"`                                 //Db User
-	Password    string   `yaml:"password" json:"password"`                         //Db Password
-	MaxOpenCons int      `yaml:"max_open_connections" json:"max_open_connections"` //Max maximum opened connections in the pool
-	MaxIdleCons int      `yaml:"max_idle_connections" json:"max_idle_connections"` //Max idle connections in the pool
+	Host        string   `yaml:"host" json:"host" validate:"required"`                              //Db host name
+	Port        string   `yaml:"port" json:"port" validate:"required"`                              //Db Port
+	Db          string   `yaml:"database" json:"database" validate:"required"`                      //Db Name
+	User        string   `yaml:"user" json:"user" validate:"required"`                              //Db User
+	Password    string   `yaml:"password" json:"password" secret:"true"`                            //Db Password
+	MaxOpenCons int      `yaml:"max_open_connections" json:"max_open_connections" validate:"min=1"` //Max maximum opened connections in the pool
+	MaxIdleCons int      `yaml:"max_idle_connections" json:"max_idle_connections" validate:"min=0"` //Max idle connections in the pool
 	Services    []string `yaml:"services" json:"services"`
 }
 
 type confFile struct {
 	Read     DbConfig `yaml:"read" json:"read"`
 	Write    DbConfig `yaml:"write" json:"write"`
-	Timezone string   `yaml:"timezone" json:"timezone"`
+	Timezone string   `yaml:"timezone" json:"timezone" validate:"required"`
 }
 
 var dbConfFile confFile
@@ -102,19 +122,22 @@ func Init(options ...Options) {
 	Connections.Read, _ = open(dbConfFile.Read, defaultOptions.readOptions)
 	Connections.Write, _ = open(dbConfFile.Write, defaultOptions.writeOptions)
 
-	go func() {
-
-		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, os.Interrupt)
+	health.Register(health.Check{
+		Name: `mysql`,
+		Check: func(ctx context.Context) error {
+			return ping(ctx, `default`, &Connections)
+		},
+	})
 
-		select {
-		case sig := <-signals:
+	lifecycle.Register(lifecycle.Component{
+		Name: `mysql`,
+		Stop: func(ctx context.Context) error {
 			Close(Connections.Read)
 			Close(Connections.Write)
-			log.Info(`Mysql connection aborted : `, sig)
-			return
-		}
-	}()
+			log.Info(`Mysql connection closed`)
+			return nil
+		},
+	})
 }
 
 
@@ -125,21 +148,22 @@ func InitWith(connection *DbConnections,path string,options ...Options){
 	connection.Read,_ = open(connection.dbConfFile.Read,connection.options.readOptions)
 	connection.Write,_ = open(connection.dbConfFile.Write,connection.options.writeOptions)
 
-	go func() {
-
-		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, os.Interrupt)
+	health.Register(health.Check{
+		Name: `mysql:` + connection.Id,
+		Check: func(ctx context.Context) error {
+			return ping(ctx, connection.Id, connection)
+		},
+	})
 
-		select {
-		case sig := <-signals:
+	lifecycle.Register(lifecycle.Component{
+		Name: `mysql:` + connection.Id,
+		Stop: func(ctx context.Context) error {
 			Close(connection.Read)
 			Close(connection.Write)
-			log.Info(`Mysql connection aborted : `, sig)
-			return
-		}
-	}()
-
-
+			log.Info(`Mysql connection closed : `, path)
+			return nil
+		},
+	})
 }
 
 func (conf DbConfig) InitRead(options *stdMysql.Config) {
@@ -183,64 +207,55 @@ func open(conf DbConfig, options *stdMysql.Config) (*sql.DB, error) {
 	return con, err
 }
 
-func Close(connection *sql.DB) {
-	err := connection.Close()
-	if err != nil {
-		log.Error(`Cannot close mysql connection :`, err)
-	}
-}
-
-func parseConfig(path string,conf *confFile) {
-	config.DefaultConfigurator.Load(path,conf, func(config interface{}) {
-		conf, _ := config.(*confFile)
-
-		if conf.Timezone == `` {
-			log.Fatal(`config/database : timezone cannot be empty`)
-		}
+//Ping Ping the read and write pools of Connections and of every opened connection of ConnectionMap
+func Ping(ctx context.Context) error {
+	connections := ConnectionMap()
+	connections[`default`] = &Connections
 
-		if conf.Read.Port == `` {
-			log.Fatal(`config/database/write : port cannot be empty`)
-		}
-
-		if conf.Read.Db == `` {
-			log.Fatal(`config/database/db : db cannot be empty`)
-		}
-
-		if conf.Read.User == `` {
-			log.Fatal(`config/database/user : user cannot be empty`)
-		}
+	errs := make(config.Errors, 0)
+	for id, con := range connections {
+		errs = errs.Append(ping(ctx, id, con))
+	}
 
-		if conf.Read.Host == `` {
-			log.Fatal(`config/database/host : host cannot be empty`)
-		}
+	return errs.Err()
+}
 
-		if conf.Read.MaxOpenCons < 1 {
-			log.Fatal(`config/database/MaxOpenCons : MaxOpenCons should be greater than zero`)
+//ping Ping the read and write pools of a connection
+func ping(ctx context.Context, id string, con *DbConnections) error {
+	errs := make(config.Errors, 0)
+	for name, db := range map[string]*sql.DB{`read`: con.Read, `write`: con.Write} {
+		if db == nil {
+			continue
 		}
 
-		if conf.Write.Port == `` {
-			log.Fatal(`config/database/write : port cannot be empty`)
+		if err := db.PingContext(ctx); err != nil {
+			errs = errs.Append(fmt.Errorf(`mysql %s %s : %w`, id, name, err))
 		}
+	}
 
-		if conf.Write.Db == `` {
-			log.Fatal(`config/database/db : db cannot be empty`)
-		}
+	return errs.Err()
+}
 
-		if conf.Write.User == `` {
-			log.Fatal(`config/database/user : user cannot be empty`)
-		}
+func Close(connection *sql.DB) {
+	err := connection.Close()
+	if err != nil {
+		log.Error(`Cannot close mysql connection :`, err)
+	}
+}
 
-		if conf.Write.Host == `` {
-			log.Fatal(`config/database/host : host cannot be empty`)
-		}
+func parseConfig(path string,conf *confFile) {
+	if err := loadConfig(path, conf); err != nil {
+		log.Fatal(err)
+	}
+}
 
-		if conf.Write.MaxOpenCons < 1 {
-			log.Fatal(`config/database/MaxOpenCons : MaxOpenCons should be greater than zero`)
-		}
-	})
+//loadConfig Load and validate a database config, reporting every invalid field
+func loadConfig(path string, conf *confFile) error {
+	return config.DefaultErrorConfigurator.Load(path, conf)
 }
 
 
 func init(){
 	connectionMap = make(map[string]*DbConnections,0)
+	config.RegisterType(`config/database`, confFile{})
 }
\ No newline at end of file
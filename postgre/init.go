@@ -1,12 +1,16 @@
 package postgre
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"github.com/danakum/go-util/app/lifecycle"
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/config/configfs"
+	"github.com/danakum/go-util/health"
 	"github.com/danakum/go-util/log"
 	_ "github.com/lib/pq"
 	"gopkg.in/yaml.v2"
-	"io/ioutil"
 )
 
 type DbConnections struct {
@@ -18,11 +22,11 @@ type DbConnections struct {
 var Connections DbConnections
 
 type DbConfig struct {
-	Host     string   `yaml:"host" json:"host"`         //Db host name
-	Port     string   `yaml:"port" json:"port"`         //Db Port
-	Db       string   `yaml:"database" json:"database"` //Db Name
-	User     string   `yaml:"user" json:"user"`         //Db User
-	Password string   `yaml:"password" json:"password"` //Db Password
+	Host     string   `yaml:"host" json:"host"`                       //Db host name
+	Port     string   `yaml:"port" json:"port"`                       //Db Port
+	Db       string   `yaml:"database" json:"database"`               //Db Name
+	User     string   `yaml:"user" json:"user"`                       //Db User
+	Password string   `yaml:"password" json:"password" secret:"true"` //Db Password
 	Services []string `yaml:"services" json:"services"`
 }
 
@@ -33,11 +37,30 @@ type confFile struct {
 
 var dbConfFile confFile
 
+func init() {
+	config.RegisterType(`config/postgres`, confFile{})
+}
+
 func Init() {
 
 	parseConfig()
 	Connections.Write, _ = open(dbConfFile.Write)
 
+	health.Register(health.Check{
+		Name: `postgres`,
+		Check: func(ctx context.Context) error {
+			return Connections.Write.PingContext(ctx)
+		},
+	})
+
+	lifecycle.Register(lifecycle.Component{
+		Name: `postgres`,
+		Stop: func(ctx context.Context) error {
+			Close(Connections.Write)
+			log.Info(`Postgres connection closed`)
+			return nil
+		},
+	})
 }
 
 func (conf DbConfig) InitWrite() {
@@ -48,7 +71,7 @@ func open(conf DbConfig) (*sql.DB, error) {
 	dbinfo := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", conf.Host, conf.User, conf.Password, conf.Db)
 
 	con, err := sql.Open(`postgres`, dbinfo)
-	log.Info("Connections  : ", conf, con)
+	log.Info(`Postgres connection : `, conf.User+`@`+conf.Host+`/`+conf.Db)
 
 	if err != nil {
 		log.Fatal(err)
@@ -68,7 +91,7 @@ func Close(connection *sql.DB) {
 }
 
 func parseConfig() {
-	file, err := ioutil.ReadFile(`config/postgres.yaml`)
+	file, err := configfs.ReadFile(`config/postgres.yaml`)
 	if err != nil {
 		log.Fatal(`Cannot open config file`, `, config/postgres.yaml, `, err)
 	}
@@ -78,6 +101,11 @@ func parseConfig() {
 		log.Fatal(`Cannot parse config file`, `config/postgres.yaml`, err)
 	}
 
+	err = config.ResolveSecrets(`config/postgres`, &dbConfFile)
+	if err != nil {
+		log.Fatal(`Cannot resolve config secrets`, `config/postgres.yaml`, err)
+	}
+
 	log.Info(`Postgres connection establish`)
 
 }
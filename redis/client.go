@@ -5,10 +5,10 @@ import "context"
 import "github.com/go-redis/redis"
 import (
 "fmt"
+"github.com/danakum/go-util/app/lifecycle"
 "github.com/danakum/go-util/config"
+"github.com/danakum/go-util/health"
 "github.com/danakum/go-util/log"
-"os"
-"os/signal"
 )
 
 var (
@@ -21,7 +21,11 @@ type Config struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
 	Database int    `json:"database"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
+}
+
+func init() {
+	config.RegisterType(`config/redis`, Config{})
 }
 
 //init redis driver or start pool
@@ -42,25 +46,32 @@ func Init() {
 
 	Client = cl
 
-	go func() {
-		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, os.Interrupt)
-
-		select {
-		case sig := <-signals:
-			Close(Client)
-			log.Info(`Redis connection aborted : `, sig)
-			break
-		}
-	}()
+	health.Register(health.Check{
+		Name: `redis`,
+		Check: func(ctx context.Context) error {
+			return Client.WithContext(ctx).Ping().Err()
+		},
+	})
+
+	lifecycle.Register(lifecycle.Component{
+		Name: `redis`,
+		Stop: func(ctx context.Context) error {
+			if err := Close(Client); err != nil {
+				return err
+			}
+
+			log.Info(`Redis connection closed`)
+			return nil
+		},
+	})
 }
 
 func loadConfig() {
 	config.DefaultConfigurator.Load(`config/redis`, &Conf, func(config interface{}) {})
 }
 
-func Close(client *redis.Client) {
-	client.Close()
+func Close(client *redis.Client) error {
+	return client.Close()
 }
 
 func Get(ctx context.Context, path string) (string, error) {
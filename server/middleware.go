@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/danakum/go-util/error-handler"
+	"github.com/danakum/go-util/log"
+	"github.com/danakum/go-util/response"
+	tctx "github.com/danakum/go-util/traceable_context"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//TraceHeader Header carrying the trace id of a request, read from the caller when it is a
+//valid uuid and always set on the response
+var TraceHeader = `X-Request-Id`
+
+var (
+	requestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: `http_requests_total`,
+		Help: `Number of http requests handled.`,
+	}, []string{`method`, `code`})
+
+	requestLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    `http_request_duration_seconds`,
+		Help:    `Latency of http requests in seconds.`,
+		Buckets: prometheus.DefBuckets,
+	}, []string{`method`, `code`})
+
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: `http_requests_in_flight`,
+		Help: `Number of http requests being handled.`,
+	})
+)
+
+func init() {
+	prometheus.MustRegister(requestCount, requestLatency, requestsInFlight)
+}
+
+//Chain Wrap a handler with the standard middleware: trace id, metrics, access log and recovery
+func Chain(handler http.Handler) http.Handler {
+	return TraceID(Metrics(AccessLog(Recovery(handler))))
+}
+
+//TraceID Carry the trace id of the request in a traceable context, so the logs of the request share it
+func TraceID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.Header.Get(TraceHeader))
+		if err != nil {
+			id = uuid.New()
+		}
+
+		w.Header().Set(TraceHeader, id.String())
+		next.ServeHTTP(w, r.WithContext(tctx.WithCtxUUID(r.Context(), id)))
+	})
+}
+
+//AccessLog Log the method, path, status and duration of every request
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		log.InfoContext(r.Context(), fmt.Sprintf(`%s %s %d %s`, r.Method, r.URL.Path, recorder.Status(), time.Since(begin)))
+	})
+}
+
+//Recovery Render panics of the handler as application errors through response.HandleError
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			//net/http aborts the response silently on this one
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.ErrorContext(r.Context(), `Http handler panicked : `, recovered, string(debug.Stack()))
+			response.HandleError(r.Context(), error_handler.NewApplocationError(`panic`, fmt.Sprint(recovered)), w)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+//Metrics Count requests and measure their latency by method and status code
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		next.ServeHTTP(recorder, r)
+
+		code := strconv.Itoa(recorder.Status())
+		requestCount.WithLabelValues(r.Method, code).Inc()
+		requestLatency.WithLabelValues(r.Method, code).Observe(time.Since(begin).Seconds())
+	})
+}
+
+//statusRecorder Remember the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+//Flush Keep streaming responses working behind the middleware
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
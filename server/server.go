@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/danakum/go-util/app/lifecycle"
+	"github.com/danakum/go-util/config"
+	"github.com/danakum/go-util/health"
+	"github.com/danakum/go-util/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//Server Http server of the application listening on the port of config/app, serving the
+//application handler behind the standard middleware chain along with /metrics, /healthz and /readyz
+type Server struct {
+	http     *http.Server
+	listener net.Listener
+	done     chan error
+}
+
+type Options func(s *http.Server)
+
+func WithAddr(addr string) Options {
+	return func(s *http.Server) {
+		s.Addr = addr
+	}
+}
+
+//WithTimeouts Override the default read, write and idle timeouts, zero values keep the defaults
+func WithTimeouts(read time.Duration, write time.Duration, idle time.Duration) Options {
+	return func(s *http.Server) {
+		if read > 0 {
+			s.ReadTimeout = read
+		}
+		if write > 0 {
+			s.WriteTimeout = write
+		}
+		if idle > 0 {
+			s.IdleTimeout = idle
+		}
+	}
+}
+
+func New(handler http.Handler, options ...Options) *Server {
+	mux := http.NewServeMux()
+	mux.Handle(`/metrics`, promhttp.Handler())
+	health.Mount(mux)
+	mux.Handle(`/`, Chain(handler))
+
+	s := &http.Server{
+		Addr:              fmt.Sprintf(`:%d`, config.AppConf.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return &Server{
+		http: s,
+		done: make(chan error, 1),
+	}
+}
+
+//Start Listen and serve in the background, the error of a failed bind is returned
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen(`tcp`, s.http.Addr)
+	if err != nil {
+		return fmt.Errorf(`server: cannot listen on %s : %w`, s.http.Addr, err)
+	}
+	s.listener = listener
+
+	go func() {
+		err := s.http.Serve(listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		if err != nil {
+			log.Error(`Http server stopped`, err)
+		}
+		s.done <- err
+	}()
+
+	log.Info(`Http server listening on ` + listener.Addr().String())
+	return nil
+}
+
+//Stop Stop accepting connections and wait for the requests in flight, at most until ctx is done
+func (s *Server) Stop(ctx context.Context) error {
+	if err := s.http.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return <-s.done
+}
+
+//Addr Address the server listens on once started
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.http.Addr
+	}
+
+	return s.listener.Addr().String()
+}
+
+//Run Create a server and register it with the lifecycle, it starts with lifecycle.Run and
+//stops before the clients it depends on so in-flight requests can still use them
+func Run(handler http.Handler, options ...Options) *Server {
+	s := New(handler, options...)
+
+	lifecycle.Register(lifecycle.Component{
+		Name:      `http`,
+		DependsOn: []string{`mysql`, `postgres`, `redis`, `mongo`, `mqtt`, `mqtt-consumer`, `zookeeper`},
+		Start:     s.Start,
+		Stop:      s.Stop,
+	})
+
+	return s
+}
@@ -1,16 +1,232 @@
 package zookeeper
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danakum/go-util/config/configfs"
+	"github.com/danakum/go-util/health"
 	"github.com/danakum/go-util/log"
 	"github.com/samuel/go-zookeeper/zk"
-	"time"
+	"gopkg.in/yaml.v2"
 )
 
+//Config Zookeeper ensemble shared by every zookeeper user of the application. Read from
+//config/zookeeper.yaml when it exists, then overridden by ZK_HOSTS, ZK_CHROOT, ZK_SESSION_TIMEOUT,
+//ZK_USER and ZK_PASSWORD
+type Config struct {
+	Hosts          []string      `yaml:"hosts" json:"hosts"`
+	Chroot         string        `yaml:"chroot" json:"chroot"`                   //Prefix of every path, ex: /my-service
+	SessionTimeout time.Duration `yaml:"session_timeout" json:"session_timeout"` //ex: 10s
+	User           string        `yaml:"user" json:"user"`                       //Digest auth user, empty to connect without auth
+	Password       string        `yaml:"password" json:"password" secret:"true"`
+}
+
+var (
+	mu     = &sync.Mutex{}
+	conf   *Config
+	client *zk.Conn
+
+	//sessionMu Guards the session state updated by the event callback, which must never wait
+	//on mu: events are delivered while Client holds it to connect and authenticate
+	sessionMu  = &sync.Mutex{}
+	expired    bool
+	reconnects = make([]func(), 0)
+)
+
+//Configure Replace the configuration, only effective before the first call to Client
+func Configure(c Config) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	conf = &c
+}
+
+//Client Shared connection, established on the first call
+func Client() (*zk.Conn, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if client != nil {
+		return client, nil
+	}
+
+	if _, err := loadedConfig(); err != nil {
+		return nil, err
+	}
+
+	c, _, err := zk.Connect(conf.Hosts, conf.SessionTimeout, zk.WithEventCallback(onEvent))
+	if err != nil {
+		return nil, fmt.Errorf(`zookeeper cannot connect to %v : %w`, conf.Hosts, err)
+	}
+
+	if conf.User != `` {
+		if err := c.AddAuth(`digest`, []byte(conf.User+`:`+conf.Password)); err != nil {
+			c.Close()
+			return nil, fmt.Errorf(`zookeeper authentication failed : %w`, err)
+		}
+	}
+
+	log.Info(`Zookeeper connected`, conf.Hosts)
+	client = c
+
+	health.Register(health.Check{
+		Name: `zookeeper`,
+		Check: func(ctx context.Context) error {
+			if state := c.State(); state != zk.StateHasSession {
+				return fmt.Errorf(`zookeeper session state is %s`, state)
+			}
+			return nil
+		},
+	})
+
+	return client, nil
+}
+
+//Connect Shared connection, stops the application when it cannot be established
 func Connect() *zk.Conn {
-	c, _, err := zk.Connect([]string{"127.0.0.1"}, time.Second) //*10)
+	c, err := Client()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	return c
 }
+
+//Close Close the shared connection, the next call to Client connects again
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if client != nil {
+		client.Close()
+		client = nil
+	}
+}
+
+//OnReconnect Run fn every time a new session replaces an expired one. Watches and ephemeral
+//nodes do not survive an expired session and have to be set again
+func OnReconnect(fn func()) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	reconnects = append(reconnects, fn)
+}
+
+//Path Absolute path of a node under the configured chroot
+func Path(p string) string {
+	mu.Lock()
+	chroot := ``
+	if c, err := loadedConfig(); err == nil {
+		chroot = c.Chroot
+	}
+	mu.Unlock()
+
+	return path.Join(`/`, chroot, p)
+}
+
+//ACL Acl of created nodes, restricted to the configured digest user when there is one
+func ACL() []zk.ACL {
+	mu.Lock()
+	defer mu.Unlock()
+
+	c, err := loadedConfig()
+	if err != nil || c.User == `` {
+		return zk.WorldACL(zk.PermAll)
+	}
+
+	return zk.DigestACL(zk.PermAll, c.User, c.Password)
+}
+
+func onEvent(event zk.Event) {
+	if event.Type != zk.EventSession {
+		return
+	}
+
+	switch event.State {
+	case zk.StateExpired:
+		log.Error(`Zookeeper session expired`)
+		sessionMu.Lock()
+		expired = true
+		sessionMu.Unlock()
+	case zk.StateDisconnected:
+		log.Warn(`Zookeeper disconnected, reconnecting`)
+	case zk.StateHasSession:
+		sessionMu.Lock()
+		wasExpired := expired
+		expired = false
+		listeners := append([]func(){}, reconnects...)
+		sessionMu.Unlock()
+
+		if !wasExpired {
+			return
+		}
+
+		log.Info(`Zookeeper session re-established`)
+		for _, fn := range listeners {
+			go fn()
+		}
+	}
+}
+
+//loadedConfig Configuration set with Configure, or loaded on first use. A configuration which
+//cannot be loaded is reported by Client, mu must be held
+func loadedConfig() (*Config, error) {
+	if conf != nil {
+		return conf, nil
+	}
+
+	c, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf = c
+
+	return conf, nil
+}
+
+func loadConfig() (*Config, error) {
+	c := &Config{
+		Hosts:          []string{`127.0.0.1`},
+		SessionTimeout: 10 * time.Second,
+	}
+
+	byt, err := configfs.ReadFile(`config/zookeeper.yaml`)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.UnmarshalStrict(byt, c); err != nil {
+			return nil, fmt.Errorf(`zookeeper cannot decode config/zookeeper.yaml : %w`, err)
+		}
+	}
+
+	if os.Getenv(`ZK_HOSTS`) != `` {
+		c.Hosts = strings.Split(os.Getenv(`ZK_HOSTS`), `,`)
+	}
+
+	if os.Getenv(`ZK_CHROOT`) != `` {
+		c.Chroot = os.Getenv(`ZK_CHROOT`)
+	}
+
+	if os.Getenv(`ZK_SESSION_TIMEOUT`) != `` {
+		timeout, err := time.ParseDuration(os.Getenv(`ZK_SESSION_TIMEOUT`))
+		if err != nil {
+			return nil, fmt.Errorf(`zookeeper invalid ZK_SESSION_TIMEOUT : %w`, err)
+		}
+		c.SessionTimeout = timeout
+	}
+
+	if os.Getenv(`ZK_USER`) != `` {
+		c.User = os.Getenv(`ZK_USER`)
+		c.Password = os.Getenv(`ZK_PASSWORD`)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,130 @@
+package zookeeper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+//historyPrefix Name prefix of the sequential child nodes keeping every published version of a node
+const historyPrefix = `history-`
+
+//Revision A published version of a node with who published it and when
+type Revision struct {
+	ID      string    `json:"-"` //Name of the history child node
+	Data    string    `json:"data"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+//Publish Set the content of a node and record it in its history in one transaction. version is
+//the version the content is replacing, -1 to create the node
+func Publish(conn Conn, node string, data []byte, version int32, author string, comment string) error {
+	ops, err := publishOps(node, data, version, author, comment)
+	if err != nil {
+		return err
+	}
+
+	if version < 0 {
+		if err := createParents(conn, node); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.Multi(ops...); err != nil {
+		return fmt.Errorf(`zookeeper cannot publish %s : %w`, node, err)
+	}
+
+	return nil
+}
+
+//History Every recorded revision of a node, oldest first
+func History(conn Conn, node string) ([]Revision, error) {
+	children, _, err := conn.Children(node)
+	if err != nil {
+		return nil, fmt.Errorf(`zookeeper cannot read history of %s : %w`, node, err)
+	}
+
+	sort.Strings(children)
+
+	revisions := make([]Revision, 0, len(children))
+	for _, child := range children {
+		if !strings.HasPrefix(child, historyPrefix) {
+			continue
+		}
+
+		revision, err := readRevision(conn, node, child)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+//Rollback Publish the content of a previous revision again, recorded as a new revision by author
+func Rollback(conn Conn, node string, id string, author string) error {
+	revision, err := readRevision(conn, node, id)
+	if err != nil {
+		return err
+	}
+
+	_, stat, err := conn.Get(node)
+	if err != nil {
+		return fmt.Errorf(`zookeeper cannot read path %s : %w`, node, err)
+	}
+
+	return Publish(conn, node, []byte(revision.Data), stat.Version, author, `rollback to `+id)
+}
+
+//publishOps Operations replacing the content of a node and recording the new revision
+func publishOps(node string, data []byte, version int32, author string, comment string) ([]interface{}, error) {
+	revision, err := json.Marshal(Revision{
+		Data:    string(data),
+		Author:  author,
+		Time:    time.Now().UTC(),
+		Comment: comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]interface{}, 0, 2)
+	if version < 0 {
+		ops = append(ops, &zk.CreateRequest{Path: node, Data: data, Acl: ACL()})
+	} else {
+		ops = append(ops, &zk.SetDataRequest{Path: node, Data: data, Version: version})
+	}
+
+	return append(ops, &zk.CreateRequest{
+		Path:  node + `/` + historyPrefix,
+		Data:  revision,
+		Acl:   ACL(),
+		Flags: zk.FlagSequence,
+	}), nil
+}
+
+func readRevision(conn Conn, node string, id string) (Revision, error) {
+	byt, _, err := conn.Get(node + `/` + id)
+	if errors.Is(err, zk.ErrNoNode) {
+		return Revision{}, fmt.Errorf(`zookeeper revision %s of %s does not exist`, id, node)
+	}
+	if err != nil {
+		return Revision{}, fmt.Errorf(`zookeeper cannot read revision %s of %s : %w`, id, node, err)
+	}
+
+	revision := Revision{}
+	if err := json.Unmarshal(byt, &revision); err != nil {
+		return Revision{}, fmt.Errorf(`zookeeper cannot decode revision %s of %s : %w`, id, node, err)
+	}
+	revision.ID = id
+
+	return revision, nil
+}
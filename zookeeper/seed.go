@@ -0,0 +1,189 @@
+package zookeeper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+//Conn Operations of *zk.Conn used to seed config nodes, so a stand-in can replace a live server
+type Conn interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Multi(ops ...interface{}) ([]zk.MultiResponse, error)
+}
+
+//Change Content a znode should have compared to what it has
+type Change struct {
+	Path    string
+	Current []byte //nil when the node does not exist
+	Desired []byte
+	Version int32 //version of the node when it was compared, -1 when it does not exist
+}
+
+func (c Change) Exists() bool {
+	return c.Version > -1
+}
+
+//Diff Line by line difference between the current and the desired content
+func (c Change) Diff() string {
+	return lineDiff(c.Path, c.Current, c.Desired)
+}
+
+//Plan Compare the desired content of nodes with zookeeper. Only nodes whose content differs are
+//returned, json documents are compared regardless of formatting
+func Plan(conn Conn, nodes map[string][]byte) ([]Change, error) {
+	paths := make([]string, 0, len(nodes))
+	for p := range nodes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	changes := make([]Change, 0)
+	for _, p := range paths {
+		desired := normalize(nodes[p])
+
+		current, stat, err := conn.Get(p)
+		if errors.Is(err, zk.ErrNoNode) {
+			changes = append(changes, Change{Path: p, Desired: desired, Version: -1})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`zookeeper cannot read path %s : %w`, p, err)
+		}
+
+		current = normalize(current)
+		if bytes.Equal(current, desired) {
+			continue
+		}
+
+		changes = append(changes, Change{Path: p, Current: current, Desired: desired, Version: stat.Version})
+	}
+
+	return changes, nil
+}
+
+//Apply Write every change in a single transaction, recording a revision by author for each node.
+//Existing nodes are checked against the version seen by Plan, so nothing is written if any of
+//them was modified in between
+func Apply(conn Conn, changes []Change, author string) error {
+	ops := make([]interface{}, 0, len(changes)*2)
+	for _, change := range changes {
+		if !change.Exists() {
+			if err := createParents(conn, change.Path); err != nil {
+				return err
+			}
+		}
+
+		changeOps, err := publishOps(change.Path, change.Desired, change.Version, author, ``)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, changeOps...)
+	}
+
+	if len(ops) < 1 {
+		return nil
+	}
+
+	responses, err := conn.Multi(ops...)
+	if err != nil {
+		for i, response := range responses {
+			if response.Error != nil {
+				return fmt.Errorf(`zookeeper cannot update %s : %w`, changes[i/2].Path, response.Error)
+			}
+		}
+		return fmt.Errorf(`zookeeper cannot update config nodes : %w`, err)
+	}
+
+	return nil
+}
+
+func createParents(conn Conn, node string) error {
+	parts := strings.Split(strings.Trim(path.Dir(node), `/`), `/`)
+	current := ``
+	for _, part := range parts {
+		if part == `` {
+			continue
+		}
+
+		current += `/` + part
+		_, err := conn.Create(current, nil, 0, ACL())
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return fmt.Errorf(`zookeeper cannot create path %s : %w`, current, err)
+		}
+	}
+
+	return nil
+}
+
+//normalize Indent json documents so formatting differences do not show up as changes
+func normalize(data []byte) []byte {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return data
+	}
+
+	indented, err := json.MarshalIndent(document, ``, `  `)
+	if err != nil {
+		return data
+	}
+
+	return indented
+}
+
+//lineDiff Unified style diff of two documents based on their longest common subsequence of lines
+func lineDiff(name string, from []byte, to []byte) string {
+	a, b := splitLines(from), splitLines(to)
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "--- %s (zookeeper)\n+++ %s (local)\n", name, name)
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(out, "  %s\n", a[i])
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(out, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(out, "+ %s\n", b[j])
+			j++
+		}
+	}
+
+	return out.String()
+}
+
+func splitLines(data []byte) []string {
+	if len(data) < 1 {
+		return nil
+	}
+
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
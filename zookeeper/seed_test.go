@@ -0,0 +1,252 @@
+package zookeeper
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+//memConn In memory stand-in of a zookeeper server for the operations of Conn
+type memConn struct {
+	nodes    map[string]*memNode
+	sequence int
+}
+
+type memNode struct {
+	data    []byte
+	version int32
+}
+
+func newMemConn() *memConn {
+	return &memConn{nodes: map[string]*memNode{`/`: {}}}
+}
+
+func (c *memConn) Get(p string) ([]byte, *zk.Stat, error) {
+	node, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+
+	return node.data, &zk.Stat{Version: node.version}, nil
+}
+
+func (c *memConn) Children(p string) ([]string, *zk.Stat, error) {
+	node, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+
+	children := make([]string, 0)
+	for child := range c.nodes {
+		if child != `/` && path.Dir(child) == p {
+			children = append(children, path.Base(child))
+		}
+	}
+	sort.Strings(children)
+
+	return children, &zk.Stat{Version: node.version}, nil
+}
+
+func (c *memConn) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	if _, ok := c.nodes[path.Dir(p)]; !ok {
+		return ``, zk.ErrNoNode
+	}
+
+	if flags&zk.FlagSequence != 0 {
+		p = fmt.Sprintf(`%s%010d`, p, c.sequence)
+		c.sequence++
+	}
+
+	if _, ok := c.nodes[p]; ok {
+		return ``, zk.ErrNodeExists
+	}
+
+	c.nodes[p] = &memNode{data: data}
+	return p, nil
+}
+
+func (c *memConn) set(p string, data []byte, version int32) error {
+	node, ok := c.nodes[p]
+	if !ok {
+		return zk.ErrNoNode
+	}
+
+	if version != node.version {
+		return zk.ErrBadVersion
+	}
+
+	node.data = data
+	node.version++
+	return nil
+}
+
+//Multi Apply every operation or none of them, like a zookeeper transaction
+func (c *memConn) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	nodes := make(map[string]*memNode, len(c.nodes))
+	for p, node := range c.nodes {
+		copied := *node
+		nodes[p] = &copied
+	}
+	sequence := c.sequence
+
+	responses := make([]zk.MultiResponse, len(ops))
+	for i, op := range ops {
+		var err error
+		switch request := op.(type) {
+		case *zk.CreateRequest:
+			responses[i].String, err = c.Create(request.Path, request.Data, request.Flags, request.Acl)
+		case *zk.SetDataRequest:
+			err = c.set(request.Path, request.Data, request.Version)
+		default:
+			err = fmt.Errorf(`unsupported operation %T`, op)
+		}
+
+		if err != nil {
+			c.nodes, c.sequence = nodes, sequence
+			responses[i].Error = err
+			return responses, err
+		}
+	}
+
+	return responses, nil
+}
+
+func (c *memConn) history(p string) []string {
+	children, _, _ := c.Children(p)
+
+	revisions := make([]string, 0)
+	for _, child := range children {
+		if strings.HasPrefix(child, historyPrefix) {
+			revisions = append(revisions, child)
+		}
+	}
+
+	return revisions
+}
+
+func TestPlan(t *testing.T) {
+	conn := newMemConn()
+	conn.nodes[`/config`] = &memNode{}
+	conn.nodes[`/config/app`] = &memNode{data: []byte(`{"port":80,"debug":false}`), version: 3}
+	conn.nodes[`/config/redis`] = &memNode{data: []byte(`{"host":"old"}`), version: 1}
+
+	changes, err := Plan(conn, map[string][]byte{
+		`/config/app`:   []byte(`{"debug": false, "port": 80}`),
+		`/config/redis`: []byte(`{"host":"new"}`),
+		`/config/mysql`: []byte(`{"host":"db"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf(`expected changes of mysql and redis only, got %+v`, changes)
+	}
+
+	if changes[0].Path != `/config/mysql` || changes[0].Exists() || changes[0].Current != nil {
+		t.Errorf(`expected mysql to be created, got %+v`, changes[0])
+	}
+
+	if changes[1].Path != `/config/redis` || changes[1].Version != 1 || !strings.Contains(changes[1].Diff(), `+   "host": "new"`) {
+		t.Errorf(`expected redis to be updated from version 1, got %+v %s`, changes[1], changes[1].Diff())
+	}
+}
+
+func TestApply(t *testing.T) {
+	conn := newMemConn()
+	conn.nodes[`/config`] = &memNode{}
+	conn.nodes[`/config/redis`] = &memNode{data: []byte(`{"host":"old"}`), version: 1}
+
+	changes, err := Plan(conn, map[string][]byte{
+		`/config/redis`:         []byte(`{"host":"new"}`),
+		`/service/config/mysql`: []byte(`{"host":"db"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Apply(conn, changes, `tester`); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, stat, _ := conn.Get(`/config/redis`); string(data) != string(normalize([]byte(`{"host":"new"}`))) || stat.Version != 2 {
+		t.Errorf(`expected redis to be updated to version 2, got %s %+v`, data, stat)
+	}
+
+	if _, _, err := conn.Get(`/service/config/mysql`); err != nil {
+		t.Errorf(`expected mysql to be created : %s`, err)
+	}
+
+	revisions, err := History(conn, `/config/redis`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].Author != `tester` {
+		t.Errorf(`expected one revision by tester, got %+v`, revisions)
+	}
+
+	if changes, _ := Plan(conn, map[string][]byte{`/config/redis`: []byte(`{"host":"new"}`)}); len(changes) != 0 {
+		t.Errorf(`expected no change after apply, got %+v`, changes)
+	}
+}
+
+func TestApplyVersionConflict(t *testing.T) {
+	conn := newMemConn()
+	conn.nodes[`/config`] = &memNode{}
+	conn.nodes[`/config/app`] = &memNode{data: []byte(`{"port":80}`), version: 1}
+	conn.nodes[`/config/redis`] = &memNode{data: []byte(`{"host":"old"}`), version: 1}
+
+	changes, err := Plan(conn, map[string][]byte{
+		`/config/app`:   []byte(`{"port":81}`),
+		`/config/redis`: []byte(`{"host":"new"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//modified by someone else between plan and apply
+	if err := conn.set(`/config/redis`, []byte(`{"host":"other"}`), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Apply(conn, changes, `tester`)
+	if !errors.Is(err, zk.ErrBadVersion) || !strings.Contains(err.Error(), `/config/redis`) {
+		t.Fatalf(`expected a version conflict on redis, got %v`, err)
+	}
+
+	if data, _, _ := conn.Get(`/config/app`); string(data) != `{"port":80}` {
+		t.Errorf(`expected app to be left untouched, got %s`, data)
+	}
+
+	if revisions := conn.history(`/config/app`); len(revisions) != 0 {
+		t.Errorf(`expected no revision to be recorded, got %v`, revisions)
+	}
+}
+
+func TestCreateParents(t *testing.T) {
+	conn := newMemConn()
+	conn.nodes[`/a`] = &memNode{}
+
+	if err := createParents(conn, `/a/b/c/node`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{`/a`, `/a/b`, `/a/b/c`} {
+		if _, ok := conn.nodes[p]; !ok {
+			t.Errorf(`expected %s to be created`, p)
+		}
+	}
+
+	if _, ok := conn.nodes[`/a/b/c/node`]; ok {
+		t.Error(`expected the node itself not to be created`)
+	}
+
+	if err := createParents(conn, `/node`); err != nil {
+		t.Errorf(`expected nothing to create under the root, got %s`, err)
+	}
+}